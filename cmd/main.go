@@ -1,7 +1,10 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"runtime"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -9,17 +12,43 @@ import (
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/component-base/cli"
 
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/checkdocs"
 	"github.com/stlaz/psachecker/pkg/clusterinspect"
+	"github.com/stlaz/psachecker/pkg/podfix"
+	"github.com/stlaz/psachecker/pkg/printers"
+	"github.com/stlaz/psachecker/pkg/scandiff"
+	"github.com/stlaz/psachecker/pkg/watch"
+	"github.com/stlaz/psachecker/pkg/webhook"
 	"github.com/stlaz/psachecker/pkg/workloadinspect"
 )
 
+const (
+	// exitPolicyFailure is the exit code of the runs whose results failed a
+	// policy gate such as --max-level or --strict
+	exitPolicyFailure = 1
+	// exitError is the exit code of the runs that failed to evaluate the
+	// objects, e.g. because the cluster was unreachable
+	exitError = 2
+)
+
 func main() {
 	flags := pflag.NewFlagSet("psachecker", pflag.ExitOnError)
 	pflag.CommandLine = flags
 
 	validationCmd := newCmd()
-	os.Exit(cli.Run(validationCmd))
+	if err := cli.RunNoErrOutput(validationCmd); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+}
 
+func exitCode(err error) int {
+	var policyErr *admission.PolicyError
+	if errors.As(err, &policyErr) {
+		return exitPolicyFailure
+	}
+	return exitError
 }
 
 func newCmd() *cobra.Command {
@@ -38,7 +67,14 @@ func newCmd() *cobra.Command {
 	o.AddGlobalFlags(cmd.PersistentFlags())
 
 	cmd.AddCommand(workloadinspect.NewWorkloadInspectCommand(o.ClientConfigOptions))
+	cmd.AddCommand(workloadinspect.NewResourcesCommand())
 	cmd.AddCommand(clusterinspect.NewClusterInspectCommand(o.ClientConfigOptions))
+	cmd.AddCommand(scandiff.NewDiffCommand())
+	cmd.AddCommand(podfix.NewFixCommand())
+	cmd.AddCommand(checkdocs.NewExplainCommand())
+	cmd.AddCommand(webhook.NewServeCommand(o.ClientConfigOptions))
+	cmd.AddCommand(watch.NewWatchCommand(o.ClientConfigOptions))
+
 	return cmd
 }
 
@@ -46,17 +82,99 @@ type PSACheckerOptions struct {
 	ClientConfigOptions *genericclioptions.ConfigFlags
 
 	// custom flags
-	updatesOnly bool
+	contexts           []string
+	updatesOnly        bool
+	compareLabels      bool
+	generateLabels     bool
+	apply              bool
+	allowRelax         bool
+	dryRun             string
+	outputFormat       string
+	outputFile         string
+	maxLevel           string
+	policyVersion      string
+	warnPolicyVersion  string
+	auditPolicyVersion string
+	modes              []string
+	useWarnLevel       bool
+	summary            bool
+	stderrSummary      bool
+	quiet              bool
+	noHeaders          bool
+	noColor            bool
+	includeRaw         bool
+	template           string
+	sortBy             string
+	groupBy            string
+	reverse            bool
+	showCompliant      bool
+	targetLevel        string
+	exemptNamespaces   []string
+	exemptRuntimes     []string
+	exemptUsers        []string
+	psaConfig          string
+	flushCache         bool
+	ignoredControls    []string
+	progress           bool
+	maxConcurrency     int
+	maxRetries         int
 }
 
 func newPSACheckerOptions() *PSACheckerOptions {
+	clientConfigOptions := genericclioptions.NewConfigFlags(true)
+	*clientConfigOptions.Timeout = "30s"
+	// --context is registered separately so that it can be repeated
+	clientConfigOptions.Context = nil
+
 	return &PSACheckerOptions{
-		ClientConfigOptions: genericclioptions.NewConfigFlags(true),
+		ClientConfigOptions: clientConfigOptions,
 	}
 }
 
 func (opts *PSACheckerOptions) AddGlobalFlags(globalFlags *pflag.FlagSet) {
 	opts.ClientConfigOptions.AddFlags(globalFlags)
+	// the timeout is also used as the deadline for the whole run so that we don't hang on unresponsive servers
+	globalFlags.Lookup("request-timeout").Usage = "The length of time to wait before giving up on the server requests and the evaluation as a whole. Non-zero values should contain a corresponding time unit (e.g. 1s, 2m, 3h). A value of zero means don't timeout requests."
+
+	globalFlags.Lookup("cache-dir").Usage = "The directory to cache the API resources discovered from the servers in, they are rediscovered after 10 minutes or with --flush-cache."
+	globalFlags.BoolVar(&opts.flushCache, "flush-cache", false, "Discover the API resources of the servers again instead of using the ones cached in the --cache-dir.")
+
+	globalFlags.StringArrayVar(&opts.contexts, "context", nil, "The name of the kubeconfig context to use. Can be set multiple times to inspect several clusters, the namespaces in the results are then prefixed with the context name.")
 
 	globalFlags.BoolVar(&opts.updatesOnly, "updates-only", false, "Display only namespaces that need to be updated. Does not currently work for local files.")
+	globalFlags.BoolVar(&opts.compareLabels, "compare-labels", false, "Compare the computed levels with the current enforce labels of the namespaces. Does not work for local files.")
+	globalFlags.BoolVar(&opts.generateLabels, "generate-labels", false, "Print Namespace manifests with the PodSecurity labels of the --modes, enforce always included, for the computed levels instead of the results.")
+	globalFlags.BoolVar(&opts.apply, "apply", false, "Set the PodSecurity enforce labels for the computed levels on the namespaces in the cluster.")
+	globalFlags.BoolVar(&opts.allowRelax, "allow-relax", false, "Allow --apply to set enforce levels that are less restrictive than the current ones.")
+	globalFlags.StringVar(&opts.dryRun, "dry-run", "none", `Only applies with --apply. Must be "none", "server", or "client". If client strategy, only print the namespaces that would be labeled. If server strategy, submit server-side requests without persisting the labels.`)
+	globalFlags.Lookup("dry-run").NoOptDefVal = "unchanged"
+	globalFlags.StringVarP(&opts.outputFormat, "output", "o", "", "Output format. One of: json|yaml|prometheus|table|junit|csv|exceptions. Defaults to human-readable \"namespace: level\" lines.")
+	globalFlags.StringVar(&opts.outputFile, "output-file", "", "Write the results, in any of the -o formats, to this file instead of the standard output. Its missing parent directories are created.")
+	globalFlags.BoolVar(&opts.includeRaw, "include-raw", false, "Attach the unmodified responses of the PodSecurity admission of each level, their warnings, audit annotations and statuses included, to the objects of the -o json|yaml output. Meant for debugging.")
+	globalFlags.StringVar(&opts.template, "template", "", "Go template to print the results with instead of the -o formats, e.g. '{{ range .Namespaces }}{{ .Name }} {{ .Level }}{{ \"\\n\" }}{{ end }}'. The namespaces have the fields of the JSON output.")
+	globalFlags.BoolVar(&opts.summary, "summary", false, "Print the number of namespaces per level after the human-readable output.")
+	globalFlags.BoolVar(&opts.stderrSummary, "stderr-summary", false, "Print a single \"psachecker: scanned=N violations=N highest=LEVEL\" line to the standard error output after the results of any output format, for scripts. The violations are the objects requiring a more privileged level than --max-level, or restricted if it is not set.")
+	globalFlags.BoolVar(&opts.noHeaders, "no-headers", false, "Do not print the header row of the -o table output.")
+	globalFlags.BoolVar(&opts.noColor, "no-color", false, "Do not color the levels in the human-readable and table outputs. They are only colored on a terminal and unless the NO_COLOR environment variable is set.")
+	globalFlags.BoolVarP(&opts.quiet, "quiet", "q", false, "Do not print the results, only the errors such as the namespaces exceeding --max-level. Useful with the exit code in scripts.")
+	globalFlags.StringVar(&opts.sortBy, "sort", printers.SortByName, "Order the namespaces in the output by their name or by their level, the most privileged first. One of: name|level.")
+	globalFlags.StringVar(&opts.groupBy, "group-by", printers.GroupByNamespace, "Group the objects in the human-readable and table outputs by their namespace or by their kind. One of: namespace|kind.")
+	globalFlags.BoolVar(&opts.reverse, "reverse", false, "Reverse the order of the namespaces set by --sort.")
+	globalFlags.StringVar(&opts.policyVersion, "policy-version", admission.PolicyVersionAuto, "The PodSecurity policy version to evaluate against, e.g. \"v1.23\" or \"latest\". Defaults to the version of the server, or to latest for local files. inspect-workloads accepts a comma-separated list of versions to compare the levels under each of them.")
+	globalFlags.StringVar(&opts.warnPolicyVersion, "warn-policy-version", "", "The PodSecurity policy version to evaluate the warn level against. Defaults to --policy-version.")
+	globalFlags.StringVar(&opts.auditPolicyVersion, "audit-policy-version", "", "The PodSecurity policy version to evaluate the audit level against. Defaults to --policy-version.")
+	globalFlags.BoolVar(&opts.useWarnLevel, "use-warn-level", false, "Report the levels of the warn mode, evaluated against the --warn-policy-version, as the enforce levels.")
+	globalFlags.StringSliceVar(&opts.modes, "modes", []string{string(admission.ModeEnforce)}, "Comma-separated list of the PodSecurity modes to display the computed levels for. Any of: enforce|warn|audit.")
+
+	globalFlags.StringSliceVar(&opts.exemptNamespaces, "exempt-namespace", nil, "Comma-separated list of namespaces exempt from the PodSecurity evaluation, as configured in the PodSecurity admission of the cluster.")
+	globalFlags.StringSliceVar(&opts.exemptRuntimes, "exempt-runtime-class", nil, "Comma-separated list of runtime classes exempt from the PodSecurity evaluation, as configured in the PodSecurity admission of the cluster.")
+	globalFlags.StringVar(&opts.psaConfig, "psa-config", "", "Path to the AdmissionConfiguration file of the API server, or to the PodSecurityConfiguration of its PodSecurity plugin. Its exemptions are added to the --exempt-* ones, its default versions are used unless the --*policy-version flags are set and its default levels apply to the namespaces without PodSecurity labels.")
+	globalFlags.BoolVar(&opts.progress, "progress", false, "Report the number of the evaluated namespaces or objects to the standard error output. Enabled by default if it is a terminal.")
+	globalFlags.StringSliceVar(&opts.ignoredControls, "ignore-control", nil, "PodSecurity check to leave out of the level computation, e.g. \"hostPathVolumes\". The results note the waived checks the objects failed. Can be set multiple times.")
+	globalFlags.StringSliceVar(&opts.exemptUsers, "exempt-user", nil, "Comma-separated list of users exempt from the PodSecurity evaluation, as configured in the PodSecurity admission of the cluster. Matched against the user set by --as.")
+	globalFlags.IntVar(&opts.maxConcurrency, "max-concurrency", runtime.NumCPU(), "The maximum number of objects to evaluate at the same time.")
+	globalFlags.IntVar(&opts.maxRetries, "max-retries", 3, "The maximum number of times to retry the requests that failed with transient errors, e.g. throttling by the API server.")
+	globalFlags.StringVar(&opts.targetLevel, "target-level", "", "The enforce level the namespaces are expected to meet, used by --strict and --show-compliant. One of: privileged|baseline|restricted.")
+	globalFlags.BoolVar(&opts.showCompliant, "show-compliant", true, "Show the namespaces that already meet the --target-level, or the restricted level if it is not set. Use --show-compliant=false to only show the namespaces that need attention.")
+	globalFlags.StringVar(&opts.maxLevel, "max-level", "", "Fail if any namespace requires a more privileged level than this one. One of: privileged|baseline|restricted.")
 }
@@ -2,30 +2,101 @@ package admission
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/workqueue"
 	psadmission "k8s.io/pod-security-admission/admission"
 	psadmissionapi "k8s.io/pod-security-admission/admission/api"
 	psapi "k8s.io/pod-security-admission/api"
 	"k8s.io/pod-security-admission/policy"
+
+	"k8s.io/klog/v2"
 )
 
 type ParallelAdmission struct {
 	privileged *psadmission.Admission
 	baseline   *psadmission.Admission
 	restricted *psadmission.Admission
+
+	checks           []*checkEvaluator
+	ignoredChecks    []*checkEvaluator
+	podSpecExtractor psadmission.PodSpecExtractor
+	policyVersions   PolicyVersions
+	exemptions       psadmissionapi.PodSecurityExemptions
+	username         string
+	onlyCheck        psapi.Level
+
+	// maxConcurrency is the maximum number of objects evaluated at the same time
+	maxConcurrency int
+	// maxConcurrencyPerNamespace is the maximum number of objects of the same
+	// namespace evaluated at the same time, zero if there is no such limit
+	maxConcurrencyPerNamespace int
+	progress                   ProgressFunc
+}
+
+// ProgressFunc is called by the workers each time an object or a namespace was
+// evaluated, with the number of the evaluated ones so far and the total
+type ProgressFunc func(done, total int, what string)
+
+type ParallelAdmissionOptions struct {
+	PolicyVersions PolicyVersions
+	// Exemptions are the namespaces, users and runtime classes exempt from the evaluation
+	Exemptions psadmissionapi.PodSecurityExemptions
+	// Username is the user the objects are evaluated as being created by
+	Username string
+	// MaxConcurrency is the maximum number of objects evaluated at the same time
+	MaxConcurrency int
+	// MaxConcurrencyPerNamespace is the maximum number of objects of the same
+	// namespace evaluated at the same time, within the MaxConcurrency. Zero
+	// means no limit other than the MaxConcurrency.
+	MaxConcurrencyPerNamespace int
+	// IgnoredChecks are the IDs of the PodSecurity checks left out of the
+	// evaluation, the levels are computed as if they were waived
+	IgnoredChecks []string
+	// Progress is called as the evaluation progresses, if set
+	Progress ProgressFunc
+	// OnlyCheck evaluates the objects against this level first and against
+	// the more privileged levels only if it does not allow them, instead of
+	// against all of the levels. The more restrictive levels are not
+	// evaluated, the objects are reported at this level at most.
+	OnlyCheck psapi.Level
 }
 
 type ParallelAdmissionResult struct {
 	Privileged, Baseline, Restricted *admissionv1.AdmissionResponse
+
+	// FailedChecks lists the PodSecurity checks the object did not pass
+	FailedChecks []FailedCheck
+	// WaivedChecks lists the ignored PodSecurity checks the object did not
+	// pass, they are not reflected in the levels
+	WaivedChecks []FailedCheck
+
+	// WarnLevel and AuditLevel are the levels required for the object not to
+	// trigger warnings and audit annotations, respectively
+	WarnLevel, AuditLevel Level
+
+	// Exemption is set if the object is exempt from the evaluation, the
+	// admissions allow such objects at any level
+	Exemption Exemption
+	// NoContainers is set if the pod spec of the object has no containers of
+	// any kind, e.g. a malformed template, none of the checks has anything
+	// to evaluate then
+	NoContainers bool
+
+	// PolicyVersion is the policy version the object was evaluated against
+	// for its enforce level
+	PolicyVersion psapi.Version
 }
 
 type AdmissionResultsKey struct {
@@ -36,12 +107,77 @@ type AdmissionResultsKey struct {
 
 type AdmissionResultsMap map[AdmissionResultsKey]*ParallelAdmissionResult
 
+// admitted returns whether the object would be admitted without issues.
+// Pods get denied by the admission, pod controllers are always allowed
+// and only receive warnings from the admission instead.
+func admitted(resp *admissionv1.AdmissionResponse) bool {
+	return resp.Allowed && len(resp.Warnings) == 0
+}
+
+func admissionMessage(resp *admissionv1.AdmissionResponse) string {
+	if !resp.Allowed && resp.Result != nil {
+		return resp.Result.Message
+	}
+	return strings.Join(resp.Warnings, "; ")
+}
+
+func (r *ParallelAdmissionResult) responseForLevel(level psapi.Level) *admissionv1.AdmissionResponse {
+	switch level {
+	case psapi.LevelPrivileged:
+		return r.Privileged
+	case psapi.LevelBaseline:
+		return r.Baseline
+	case psapi.LevelRestricted:
+		return r.Restricted
+	default:
+		return nil
+	}
+}
+
+func (r *ParallelAdmissionResult) messageForLevel(level psapi.Level) string {
+	resp := r.responseForLevel(level)
+	if resp == nil {
+		return ""
+	}
+	return admissionMessage(resp)
+}
+
+// auditAnnotations returns the audit annotations of the response of the
+// level the way the API server records them, prefixed by the PodSecurity
+// label prefix. The enforce policy is left out, it is always the evaluated
+// level rather than the one of the namespace.
+func (r *ParallelAdmissionResult) auditAnnotations(level psapi.Level) map[string]string {
+	resp := r.responseForLevel(level)
+	if resp == nil {
+		return nil
+	}
+
+	var annotations map[string]string
+	for k, v := range resp.AuditAnnotations {
+		if k == psapi.EnforcedPolicyAnnotationKey {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[auditAnnotationPrefix+k] = v
+	}
+	return annotations
+}
+
 func (r *ParallelAdmissionResult) String() string {
+
 	resultString := func(resp *admissionv1.AdmissionResponse) string {
-		if resp.Allowed {
+		if resp == nil {
+			return "not evaluated"
+		}
+		if admitted(resp) {
 			return "allowed"
 		}
-		return fmt.Sprintf("%s: %s", resp.Result.Status, resp.Result.Message)
+		if !resp.Allowed && resp.Result != nil {
+			return fmt.Sprintf("%s: %s", resp.Result.Status, resp.Result.Message)
+		}
+		return fmt.Sprintf("warning: %s", admissionMessage(resp))
 	}
 
 	return fmt.Sprintf(
@@ -52,25 +188,102 @@ func (r *ParallelAdmissionResult) String() string {
 	)
 }
 
+type admissionResponseJSON struct {
+	Allowed bool   `json:"allowed"`
+	Message string `json:"message,omitempty"`
+}
+
+func newAdmissionResponseJSON(resp *admissionv1.AdmissionResponse) *admissionResponseJSON {
+	if resp == nil {
+		return nil
+	}
+
+	ret := &admissionResponseJSON{Allowed: admitted(resp)}
+	if !ret.Allowed {
+		ret.Message = admissionMessage(resp)
+	}
+	return ret
+}
+
+func (r *ParallelAdmissionResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Level        Level                  `json:"level"`
+		Privileged   *admissionResponseJSON `json:"privileged"`
+		Baseline     *admissionResponseJSON `json:"baseline"`
+		Restricted   *admissionResponseJSON `json:"restricted"`
+		WarnLevel    Level                  `json:"warnLevel"`
+		AuditLevel   Level                  `json:"auditLevel"`
+		FailedChecks []FailedCheck          `json:"failedChecks,omitempty"`
+		WaivedChecks []FailedCheck          `json:"waivedChecks,omitempty"`
+		Exemption    Exemption              `json:"exemption,omitempty"`
+		NoContainers bool                   `json:"noContainers,omitempty"`
+	}{
+		Level:        r.Level(),
+		WarnLevel:    r.WarnLevel,
+		AuditLevel:   r.AuditLevel,
+		Privileged:   newAdmissionResponseJSON(r.Privileged),
+		Baseline:     newAdmissionResponseJSON(r.Baseline),
+		Restricted:   newAdmissionResponseJSON(r.Restricted),
+		FailedChecks: r.FailedChecks,
+		WaivedChecks: r.WaivedChecks,
+		Exemption:    r.Exemption,
+		NoContainers: r.NoContainers,
+	})
+}
+
 const LevelUnknown psapi.Level = psapi.Level("unknown")
 
-func (r *ParallelAdmissionResult) MostRestrictivePolicy() psapi.Level {
-	if r.Restricted == nil || r.Baseline == nil || r.Privileged == nil {
-		return LevelUnknown
+// auditAnnotationPrefix is prepended to the audit annotations of the
+// PodSecurity admission by the API server
+const auditAnnotationPrefix = "pod-security.kubernetes.io/"
+
+// Level returns the most restrictive level that allows the object, the
+// levels that were not evaluated, see OnlyCheck, don't allow it
+func (r *ParallelAdmissionResult) Level() Level {
+	if r.Privileged == nil {
+		return LevelUnknownValue
 	}
 
 	switch {
-	case r.Restricted.Allowed:
-		return psapi.LevelRestricted
-	case r.Baseline.Allowed:
-		return psapi.LevelBaseline
+	case r.Restricted != nil && admitted(r.Restricted):
+		return LevelRestrictedValue
+	case r.Baseline != nil && admitted(r.Baseline):
+		return LevelBaselineValue
 	default:
-		return psapi.LevelPrivileged
+		return LevelPrivilegedValue
 	}
 }
 
-func NewParallelAdmission(kubeClient kubernetes.Interface) (*ParallelAdmission, error) {
-	evaluator, err := policy.NewEvaluator(policy.DefaultChecks()) // TODO: allow experimental checks by a flag
+// MostRestrictivePolicy is the same as Level
+func (r *ParallelAdmissionResult) MostRestrictivePolicy() Level {
+	return r.Level()
+}
+
+// NewParallelAdmission sets up admissions for each of the PodSecurity levels
+// that evaluate the objects against the given policy versions and exemptions
+func NewParallelAdmission(kubeClient kubernetes.Interface, opts *ParallelAdmissionOptions) (*ParallelAdmission, error) {
+	if opts.MaxConcurrency < 1 {
+		return nil, fmt.Errorf("the maximum concurrency must be a positive number, got %d", opts.MaxConcurrency)
+	}
+	if opts.MaxConcurrencyPerNamespace < 0 {
+		return nil, fmt.Errorf("the maximum concurrency per namespace must not be negative, got %d", opts.MaxConcurrencyPerNamespace)
+	}
+
+	// TODO: allow experimental checks by a flag
+	checks, ignoredChecks, err := withoutIgnoredChecks(policy.DefaultChecks(), opts.IgnoredChecks)
+	if err != nil {
+		return nil, err
+	}
+	evaluator, err := policy.NewEvaluator(checks)
+	if err != nil {
+		return nil, err
+	}
+
+	checkEvaluators, err := newCheckEvaluators(checks)
+	if err != nil {
+		return nil, err
+	}
+	ignoredCheckEvaluators, err := newCheckEvaluators(ignoredChecks)
 	if err != nil {
 		return nil, err
 	}
@@ -84,15 +297,18 @@ func NewParallelAdmission(kubeClient kubernetes.Interface) (*ParallelAdmission,
 	// IMPORTANT: make sure to unit-test that Namespace-object admission validation
 	//            is not influenced by nsGetter
 	nsGetter := KnowAllNamespaceGetter
-	privilegedAdm, err := setupAdmission(nsGetter, podLister, evaluator, psapi.LevelPrivileged)
+	// the admissions produce the enforce results, warn and audit are evaluated
+	// separately since they may be using different policy versions
+	policyVersion := opts.PolicyVersions.Enforce
+	privilegedAdm, err := setupAdmission(nsGetter, podLister, evaluator, psapi.LevelPrivileged, policyVersion, opts.Exemptions)
 	if err != nil {
 		return nil, err
 	}
-	baselineAdm, err := setupAdmission(nsGetter, podLister, evaluator, psapi.LevelBaseline)
+	baselineAdm, err := setupAdmission(nsGetter, podLister, evaluator, psapi.LevelBaseline, policyVersion, opts.Exemptions)
 	if err != nil {
 		return nil, err
 	}
-	restrictedAdm, err := setupAdmission(nsGetter, podLister, evaluator, psapi.LevelRestricted)
+	restrictedAdm, err := setupAdmission(nsGetter, podLister, evaluator, psapi.LevelRestricted, policyVersion, opts.Exemptions)
 	if err != nil {
 		return nil, err
 	}
@@ -101,31 +317,113 @@ func NewParallelAdmission(kubeClient kubernetes.Interface) (*ParallelAdmission,
 		privileged: privilegedAdm,
 		baseline:   baselineAdm,
 		restricted: restrictedAdm,
+
+		checks:           checkEvaluators,
+		ignoredChecks:    ignoredCheckEvaluators,
+		podSpecExtractor: &psadmission.DefaultPodSpecExtractor{},
+		policyVersions:   opts.PolicyVersions,
+		exemptions:       opts.Exemptions,
+		username:         opts.Username,
+		maxConcurrency:   opts.MaxConcurrency,
+		progress:         opts.Progress,
+		onlyCheck:        opts.OnlyCheck,
+
+		maxConcurrencyPerNamespace: opts.MaxConcurrencyPerNamespace,
 	}, nil
 }
 
 func (a *ParallelAdmission) Validate(ctx context.Context, attrs psapi.Attributes) *ParallelAdmissionResult {
-	result := &ParallelAdmissionResult{}
-	resultsWG := &sync.WaitGroup{}
-	waitForAdmission := func(wg *sync.WaitGroup, admission *psadmission.Admission, result **admissionv1.AdmissionResponse) {
-		defer wg.Done()
-		*result = admission.Validate(ctx, attrs)
+	result := &ParallelAdmissionResult{PolicyVersion: a.policyVersions.Enforce}
+	if len(a.onlyCheck) > 0 {
+		a.validateFrom(ctx, attrs, a.onlyCheck, result)
+	} else {
+		resultsWG := &sync.WaitGroup{}
+		waitForAdmission := func(wg *sync.WaitGroup, admission *psadmission.Admission, result **admissionv1.AdmissionResponse) {
+			defer wg.Done()
+			*result = admission.Validate(ctx, attrs)
+		}
+
+		resultsWG.Add(3)
+		go waitForAdmission(resultsWG, a.privileged, &result.Privileged)
+		go waitForAdmission(resultsWG, a.baseline, &result.Baseline)
+		go waitForAdmission(resultsWG, a.restricted, &result.Restricted)
+
+		resultsWG.Wait()
 	}
 
-	resultsWG.Add(3)
-	go waitForAdmission(resultsWG, a.privileged, &result.Privileged)
-	go waitForAdmission(resultsWG, a.baseline, &result.Baseline)
-	go waitForAdmission(resultsWG, a.restricted, &result.Restricted)
+	result.WarnLevel = result.Level()
+	result.AuditLevel = result.WarnLevel
+	if result.Exemption = exemptionFor(a.exemptions, a.podSpecExtractor, attrs); len(result.Exemption) > 0 {
+		// none of the checks apply
+		return result
+	}
+	if obj, err := attrs.GetObject(); err == nil {
+		if result.NoContainers = hasNoContainers(a.podSpecExtractor, obj); result.NoContainers {
+			return result
+		}
+		// the objects the restricted level allows pass all of the checks
+		if result.Level() != LevelRestrictedValue {
+			result.FailedChecks = evaluateChecks(a.checks, a.podSpecExtractor, a.policyVersions.Enforce, obj)
+		}
+		result.WaivedChecks = evaluateChecks(a.ignoredChecks, a.podSpecExtractor, a.policyVersions.Enforce, obj)
 
-	resultsWG.Wait()
+		if a.policyVersions.Warn != a.policyVersions.Enforce {
+			result.WarnLevel = levelForFailedChecks(evaluateChecks(a.checks, a.podSpecExtractor, a.policyVersions.Warn, obj))
+		}
+		if a.policyVersions.Audit != a.policyVersions.Enforce {
+			result.AuditLevel = levelForFailedChecks(evaluateChecks(a.checks, a.podSpecExtractor, a.policyVersions.Audit, obj))
+		}
+	}
 
 	return result
 }
 
+// validateFrom evaluates the object against the level and the more
+// privileged ones in turn until one of them allows it. The levels are
+// cumulative, the more privileged levels than the one that allows the object
+// are given its response as they would allow it as well.
+func (a *ParallelAdmission) validateFrom(ctx context.Context, attrs psapi.Attributes, level psapi.Level, result *ParallelAdmissionResult) {
+	ladder := []struct {
+		level     psapi.Level
+		admission *psadmission.Admission
+		response  **admissionv1.AdmissionResponse
+	}{
+		{psapi.LevelRestricted, a.restricted, &result.Restricted},
+		{psapi.LevelBaseline, a.baseline, &result.Baseline},
+		{psapi.LevelPrivileged, a.privileged, &result.Privileged},
+	}
+
+	var allowed *admissionv1.AdmissionResponse
+	for _, step := range ladder {
+		switch {
+		case LevelValue(level) < LevelValue(step.level):
+			// not evaluated
+		case allowed != nil:
+			*step.response = allowed
+		default:
+			*step.response = step.admission.Validate(ctx, attrs)
+			if admitted(*step.response) {
+				allowed = *step.response
+			}
+		}
+	}
+}
+
+// hasNoContainers returns whether the object has a pod spec without any
+// containers, init containers or ephemeral containers. The objects that only
+// have init containers are evaluated as usual.
+func hasNoContainers(podSpecExtractor psadmission.PodSpecExtractor, obj runtime.Object) bool {
+	_, podSpec, err := podSpecExtractor.ExtractPodSpec(obj)
+	if err != nil || podSpec == nil {
+		return false
+	}
+	return len(podSpec.Containers) == 0 && len(podSpec.InitContainers) == 0 && len(podSpec.EphemeralContainers) == 0
+}
+
 func (a *ParallelAdmission) ValidateResources(ctx context.Context, localResources bool, defaultNamespace *string, resources ...*resource.Info) (AdmissionResultsMap, error) {
-	results := AdmissionResultsMap{}
+	keys := make([]AdmissionResultsKey, 0, len(resources))
+	attrs := make([]*psapi.AttributesRecord, 0, len(resources))
 	for _, resInfo := range resources {
-
 		var resource schema.GroupVersionResource
 		if resInfo.Mapping != nil {
 			resource = resInfo.Mapping.Resource
@@ -154,27 +452,112 @@ func (a *ParallelAdmission) ValidateResources(ctx context.Context, localResource
 			Name:      objName,
 		}
 
-		results[key] = a.Validate(ctx, &psapi.AttributesRecord{
+		keys = append(keys, key)
+		attrs = append(attrs, &psapi.AttributesRecord{
 			Namespace: objNS,
 			Name:      objName,
 			Resource:  resource,
 			Operation: admissionv1.Create,
 			Object:    resInfo.Object,
-			Username:  "", // TODO: do we need this? What's it for anyway?
+			Username:  a.username, // only used to match the exempt users
 		})
 	}
+
+	validated := make([]*ParallelAdmissionResult, len(attrs))
+	reportProgress := a.progressReporter(len(attrs), "objects")
+	order, nsSlots := a.namespaceSlots(keys)
+	workqueue.ParallelizeUntil(ctx, a.maxConcurrency, len(attrs), func(piece int) {
+		defer reportProgress()
+		i := order[piece]
+		if slots, ok := nsSlots[keys[i].Namespace]; ok {
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				return
+			}
+		}
+		// the guards save boxing the arguments of each object when not logging
+		klogV := klog.V(4)
+		if klogV.Enabled() {
+			klogV.InfoS("Evaluating object", "kind", keys[i].GVK.Kind, "namespace", keys[i].Namespace, "name", keys[i].Name)
+		}
+		validated[i] = a.Validate(ctx, attrs[i])
+		if klogV.Enabled() {
+			klogV.InfoS("Evaluated object", "kind", keys[i].GVK.Kind, "namespace", keys[i].Namespace, "name", keys[i].Name,
+				"level", validated[i].Level(), "warnLevel", validated[i].WarnLevel, "auditLevel", validated[i].AuditLevel,
+				"exemption", validated[i].Exemption)
+		}
+		if klog.V(6).Enabled() {
+			klog.InfoS("Admission results", "kind", keys[i].GVK.Kind, "namespace", keys[i].Namespace, "name", keys[i].Name, "results", validated[i].String())
+		}
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// fill the results in the order of the resources so that the results don't
+	// depend on the order in which the evaluations finished
+	results := AdmissionResultsMap{}
+	for i, key := range keys {
+		results[key] = validated[i]
+	}
 	return results, nil
 }
 
-func (a *ParallelAdmission) ValidateNamespaces(ctx context.Context, namespaces ...corev1.Namespace) (map[string]psapi.Level, error) {
-	results := make(map[string]psapi.Level)
+// namespaceSlots returns the order in which to evaluate the objects of the
+// keys along with a semaphore per namespace if the concurrency per namespace
+// is limited. The objects are then ordered round-robin by their namespaces so
+// that the workers don't wait for the objects of a single one while the
+// objects of the others are left to evaluate.
+func (a *ParallelAdmission) namespaceSlots(keys []AdmissionResultsKey) ([]int, map[string]chan struct{}) {
+	order := make([]int, 0, len(keys))
+	if a.maxConcurrencyPerNamespace == 0 || a.maxConcurrencyPerNamespace >= a.maxConcurrency {
+		for i := range keys {
+			order = append(order, i)
+		}
+		return order, nil
+	}
+
+	var namespaces []string
+	byNamespace := map[string][]int{}
+	for i, key := range keys {
+		if _, ok := byNamespace[key.Namespace]; !ok {
+			namespaces = append(namespaces, key.Namespace)
+		}
+		byNamespace[key.Namespace] = append(byNamespace[key.Namespace], i)
+	}
+	for round := 0; len(order) < len(keys); round++ {
+		for _, ns := range namespaces {
+			if nsKeys := byNamespace[ns]; round < len(nsKeys) {
+				order = append(order, nsKeys[round])
+			}
+		}
+	}
+
+	slots := make(map[string]chan struct{}, len(namespaces))
 	for _, ns := range namespaces {
-		results[ns.Name] = psapi.LevelPrivileged
+		slots[ns] = make(chan struct{}, a.maxConcurrencyPerNamespace)
+	}
+	return order, slots
+}
+
+func (a *ParallelAdmission) ValidateNamespaces(ctx context.Context, namespaces ...corev1.Namespace) (map[string]*NamespaceResult, error) {
+	levels := make([]psapi.Level, len(namespaces))
+	reportProgress := a.progressReporter(len(namespaces), "namespaces")
+	workqueue.ParallelizeUntil(ctx, a.maxConcurrency, len(namespaces), func(i int) {
+		defer reportProgress()
+		ns := namespaces[i]
+		levels[i] = psapi.LevelPrivileged
 		// loop through available levels in order of restrictivness so that more restrictive levels override previous result if they are allowed
 		for _, privilegeLevel := range []psapi.Level{psapi.LevelBaseline, psapi.LevelRestricted} {
 			newNS := ns.DeepCopy()
+			if newNS.Labels == nil {
+				newNS.Labels = map[string]string{}
+			}
 			newNS.Labels[psapi.EnforceLevelLabel] = string(privilegeLevel)
-			newNS.Labels[psapi.EnforceVersionLabel] = string(psapi.VersionLatest) // FIXME: should this be the earliest version or the current one? Which version should the admission config use?
+			newNS.Labels[psapi.EnforceVersionLabel] = a.policyVersions.Enforce.String()
 
 			// TODO:
 			// - perhaps a flag should be added to inspect all workloads instead of namespaces
@@ -197,20 +580,53 @@ func (a *ParallelAdmission) ValidateNamespaces(ctx context.Context, namespaces .
 			// If there are issues with PSa enforcement, these are passed in the
 			// results's `Warnings` attribute`
 			if len(admissionResult.Warnings) == 0 {
-				results[ns.Name] = privilegeLevel
+				levels[i] = privilegeLevel
+			} else {
+				klog.V(5).InfoS("Namespace level would disrupt its pods", "namespace", ns.Name, "level", privilegeLevel, "warnings", admissionResult.Warnings)
 			}
 
 		}
+		klog.V(4).InfoS("Evaluated namespace", "namespace", ns.Name, "level", levels[i])
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*NamespaceResult, len(namespaces))
+	for i, ns := range namespaces {
+		// the namespace evaluation only checks the enforce level
+		level := LevelValue(levels[i])
+		results[ns.Name] = &NamespaceResult{Level: level, WarnLevel: level, AuditLevel: level}
 	}
 
 	return results, nil
 }
 
+// progressReporter returns a function for the workers to call when they finish
+// evaluating one of the total items
+func (a *ParallelAdmission) progressReporter(total int, what string) func() {
+	if a.progress == nil {
+		return func() {}
+	}
+	// serialized so that the progress is reported in order
+	var lock sync.Mutex
+	var done int
+	return func() {
+		lock.Lock()
+		defer lock.Unlock()
+		done++
+		a.progress(done, total, what)
+	}
+}
+
 func setupAdmission(
 	nsGetter psadmission.NamespaceGetter,
 	podLister psadmission.PodLister,
 	evaluator policy.Evaluator,
 	admissionLevel psapi.Level,
+	policyVersion psapi.Version,
+	exemptions psadmissionapi.PodSecurityExemptions,
 ) (*psadmission.Admission, error) {
 
 	adm := &psadmission.Admission{
@@ -219,13 +635,15 @@ func setupAdmission(
 		Configuration: &psadmissionapi.PodSecurityConfiguration{
 			Defaults: psadmissionapi.PodSecurityDefaults{
 				Enforce:        string(admissionLevel),
-				EnforceVersion: psapi.VersionLatest,
+				EnforceVersion: policyVersion.String(),
 				Audit:          string(admissionLevel),
-				AuditVersion:   psapi.VersionLatest,
+				AuditVersion:   policyVersion.String(),
 				Warn:           string(admissionLevel),
-				WarnVersion:    psapi.VersionLatest,
+				WarnVersion:    policyVersion.String(),
 			},
+			Exemptions: exemptions,
 		},
+
 		NamespaceGetter: nsGetter,
 		PodLister:       podLister,
 		Metrics:         &NoopMetricsRecorder{},
@@ -237,44 +655,10 @@ func setupAdmission(
 	return adm, adm.ValidateConfiguration()
 }
 
-func MostRestrictivePolicyPerNamespace(results AdmissionResultsMap) map[string]psapi.Level {
-	aggregatedResults := make(map[string]psapi.Level)
-	for objInfo, result := range results {
-		currentPolicy, ok := aggregatedResults[objInfo.Namespace]
-		if !ok {
-			aggregatedResults[objInfo.Namespace] = result.MostRestrictivePolicy()
-		} else {
-			aggregatedResults[objInfo.Namespace] = greaterPSAPrivileges(currentPolicy, result.MostRestrictivePolicy())
-		}
-	}
-	return aggregatedResults
-}
-
-func greaterPSAPrivileges(a, b psapi.Level) psapi.Level {
-	if psapiLevelIntValue(a) >= psapiLevelIntValue(b) {
-		return a
-	}
-	return b
-}
-
-var psapiIntToLevelMapping = [4]psapi.Level{
-	psapi.LevelRestricted,
-	psapi.LevelBaseline,
-	psapi.LevelPrivileged,
-	LevelUnknown,
-}
-
-func psapiLevelIntValue(l psapi.Level) uint {
-	switch l {
-	case psapi.LevelPrivileged:
-		return 2
-	case psapi.LevelBaseline:
-		return 1
-	case psapi.LevelRestricted:
-		return 0
-	case LevelUnknown:
-		fallthrough
-	default:
-		return 3
+// greaterPrivileges returns the more privileged of the levels
+func greaterPrivileges(a, b Level) Level {
+	if b < a {
+		return b
 	}
+	return a
 }
@@ -0,0 +1,153 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/fake"
+	psapi "k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	benchmarkDeployments = 3000
+	benchmarkNamespaces  = 400
+)
+
+// benchmarkInfos returns Deployments spread across the namespaces, every
+// third of them requires a different level
+func benchmarkInfos() []*resource.Info {
+	infos := make([]*resource.Info, 0, benchmarkDeployments)
+	for i := 0; i < benchmarkDeployments; i++ {
+		deployment := &appsv1.Deployment{
+			TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: fmt.Sprintf("namespace-%d", i%benchmarkNamespaces),
+				Name:      fmt.Sprintf("deployment-%d", i),
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "app", Image: "busybox"}},
+					},
+				},
+			},
+		}
+		switch i % 3 {
+		case 1:
+			deployment.Spec.Template.Spec.HostNetwork = true
+		case 2:
+			deployment.Spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{
+				RunAsNonRoot:   pointer.Bool(true),
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			}
+			deployment.Spec.Template.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{
+				AllowPrivilegeEscalation: pointer.Bool(false),
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			}
+		}
+		infos = append(infos, &resource.Info{
+			Object:    deployment,
+			Namespace: deployment.Namespace,
+			Name:      deployment.Name,
+		})
+	}
+	return infos
+}
+
+func newBenchmarkAdmission(b *testing.B) *ParallelAdmission {
+	latest := psapi.LatestVersion()
+	adm, err := NewParallelAdmission(fake.NewSimpleClientset(), &ParallelAdmissionOptions{
+		PolicyVersions: PolicyVersions{Enforce: latest, Warn: latest, Audit: latest},
+		MaxConcurrency: 4,
+	})
+	if err != nil {
+		b.Fatalf("failed to set up the admission: %v", err)
+	}
+	return adm
+}
+
+func BenchmarkValidateResources(b *testing.B) {
+	adm := newBenchmarkAdmission(b)
+	infos := benchmarkInfos()
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := adm.ValidateResources(ctx, true, nil, infos...); err != nil {
+			b.Fatalf("failed to validate the resources: %v", err)
+		}
+	}
+}
+
+func TestParallelAdmissionResultMarshalJSON(t *testing.T) {
+	latest := psapi.LatestVersion()
+	adm, err := NewParallelAdmission(fake.NewSimpleClientset(), &ParallelAdmissionOptions{
+		PolicyVersions: PolicyVersions{Enforce: latest, Warn: latest, Audit: latest},
+		MaxConcurrency: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to set up the admission: %v", err)
+	}
+	// the Deployment with the host network
+	info := benchmarkInfos()[1]
+	results, err := adm.ValidateResources(context.Background(), true, nil, info)
+	if err != nil {
+		t.Fatalf("failed to validate the deployment: %v", err)
+	}
+
+	data, err := json.Marshal(AggregateResultsPerNamespace(results)[info.Namespace])
+	if err != nil {
+		t.Fatalf("failed to marshal the results: %v", err)
+	}
+	var nsResult struct {
+		Level   string `json:"level"`
+		Objects []struct {
+			Kind   string `json:"kind"`
+			Name   string `json:"name"`
+			Result struct {
+				Level        string `json:"level"`
+				FailedChecks []struct {
+					ID            string `json:"id"`
+					RequiredLevel string `json:"requiredLevel"`
+				} `json:"failedChecks"`
+			} `json:"result"`
+		} `json:"objects"`
+	}
+	if err := json.Unmarshal(data, &nsResult); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", data, err)
+	}
+
+	if nsResult.Level != "privileged" {
+		t.Errorf("expected the level privileged, got %q in %s", nsResult.Level, data)
+	}
+	if len(nsResult.Objects) != 1 {
+		t.Fatalf("expected a single object, got %s", data)
+	}
+	obj := nsResult.Objects[0]
+	if obj.Kind != "Deployment" || obj.Name != info.Name {
+		t.Errorf("expected the object Deployment/%s, got %s/%s", info.Name, obj.Kind, obj.Name)
+	}
+	if obj.Result.Level != "privileged" {
+		t.Errorf("expected the object level privileged, got %q in %s", obj.Result.Level, data)
+	}
+	var hostNamespaces bool
+	for _, check := range obj.Result.FailedChecks {
+		if check.ID == "hostNamespaces" {
+			hostNamespaces = true
+			if check.RequiredLevel != "privileged" {
+				t.Errorf("expected the hostNamespaces check to require privileged, got %q", check.RequiredLevel)
+			}
+		}
+	}
+	if !hostNamespaces {
+		t.Errorf("expected the hostNamespaces check to fail, got %s", data)
+	}
+}
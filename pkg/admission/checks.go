@@ -0,0 +1,165 @@
+package admission
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	psadmission "k8s.io/pod-security-admission/admission"
+	psapi "k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// FailedCheck is a PodSecurity check that an evaluated object did not pass
+type FailedCheck struct {
+	// ID is the ID of the PodSecurity check, e.g. "runAsNonRoot"
+	ID string `json:"id"`
+	// RequiredLevel is the most restrictive level that does not include the check
+	RequiredLevel Level  `json:"requiredLevel"`
+	Reason        string `json:"reason"`
+	Detail        string `json:"detail,omitempty"`
+	// Containers lists the names of the containers, init and ephemeral
+	// containers included, that violate the check. It is empty if the
+	// check failed on the pod-level fields only.
+	Containers []string `json:"containers,omitempty"`
+}
+
+// checkEvaluator evaluates a single PodSecurity check so that failures
+// can be attributed to the check ID, which the aggregated admission
+// response does not carry
+type checkEvaluator struct {
+	id        string
+	level     psapi.Level
+	evaluator policy.Evaluator
+}
+
+func newCheckEvaluators(checks []policy.Check) ([]*checkEvaluator, error) {
+	evaluators := make([]*checkEvaluator, 0, len(checks))
+	for _, check := range checks {
+		evaluator, err := policy.NewEvaluator([]policy.Check{check})
+		if err != nil {
+			return nil, err
+		}
+		evaluators = append(evaluators, &checkEvaluator{
+			id:        check.ID,
+			level:     check.Level,
+			evaluator: evaluator,
+		})
+	}
+	return evaluators, nil
+}
+
+// withoutIgnoredChecks splits the checks into the ones to evaluate and the
+// ignored ones, it fails on IDs that don't match any of the checks
+func withoutIgnoredChecks(checks []policy.Check, ignored []string) ([]policy.Check, []policy.Check, error) {
+	ignoredIDs := make(map[string]bool, len(ignored))
+	for _, id := range ignored {
+		ignoredIDs[id] = true
+	}
+
+	var evaluated, ignoredChecks []policy.Check
+	for _, check := range checks {
+		if ignoredIDs[check.ID] {
+			ignoredChecks = append(ignoredChecks, check)
+			delete(ignoredIDs, check.ID)
+		} else {
+			evaluated = append(evaluated, check)
+		}
+	}
+
+	if len(ignoredIDs) > 0 {
+		unknown := make([]string, 0, len(ignoredIDs))
+		for id := range ignoredIDs {
+			unknown = append(unknown, id)
+		}
+		sort.Strings(unknown)
+		known := make([]string, 0, len(checks))
+		for _, check := range checks {
+			known = append(known, check.ID)
+		}
+		return nil, nil, fmt.Errorf("unknown PodSecurity checks %s, must be any of: %s", strings.Join(unknown, ", "), strings.Join(known, ", "))
+	}
+	return evaluated, ignoredChecks, nil
+}
+
+func (e *checkEvaluator) requiredLevel() Level {
+	if e.level == psapi.LevelRestricted {
+		return LevelBaselineValue
+	}
+	return LevelPrivilegedValue
+}
+
+func evaluateChecks(checks []*checkEvaluator, podSpecExtractor psadmission.PodSpecExtractor, policyVersion psapi.Version, obj runtime.Object) []FailedCheck {
+	podMetadata, podSpec, err := podSpecExtractor.ExtractPodSpec(obj)
+	if err != nil || podSpec == nil {
+		// not an object with a pod spec, nothing to explain
+		return nil
+	}
+
+	var failed []FailedCheck
+	for _, check := range checks {
+		lv := psapi.LevelVersion{Level: check.level, Version: policyVersion}
+		for _, result := range check.evaluator.EvaluatePod(lv, podMetadata, podSpec) {
+			if result.Allowed {
+				continue
+			}
+			failed = append(failed, FailedCheck{
+				ID:            check.id,
+				RequiredLevel: check.requiredLevel(),
+				Reason:        result.ForbiddenReason,
+				Detail:        result.ForbiddenDetail,
+				Containers:    check.failingContainers(lv, podMetadata, podSpec),
+			})
+		}
+	}
+	return failed
+}
+
+// failingContainers evaluates the check against each container of the pod
+// spec on its own, keeping the pod-level fields, to find the containers the
+// check fails for. Containers are not blamed for failures of the pod-level
+// fields alone.
+func (e *checkEvaluator) failingContainers(lv psapi.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) []string {
+	failsFor := func(spec *corev1.PodSpec) bool {
+		for _, result := range e.evaluator.EvaluatePod(lv, podMetadata, spec) {
+			if !result.Allowed {
+				return true
+			}
+		}
+		return false
+	}
+
+	// the checks only read the pod spec, the shallow copies with the
+	// containers sliced one by one don't need to copy any of the fields
+	spec := *podSpec
+	spec.Containers, spec.InitContainers, spec.EphemeralContainers = nil, nil, nil
+	if failsFor(&spec) {
+		return nil
+	}
+
+	var containers []string
+	for i := range podSpec.InitContainers {
+		spec.InitContainers = podSpec.InitContainers[i : i+1]
+		if failsFor(&spec) {
+			containers = append(containers, podSpec.InitContainers[i].Name)
+		}
+	}
+	spec.InitContainers = nil
+	for i := range podSpec.Containers {
+		spec.Containers = podSpec.Containers[i : i+1]
+		if failsFor(&spec) {
+			containers = append(containers, podSpec.Containers[i].Name)
+		}
+	}
+	spec.Containers = nil
+	for i := range podSpec.EphemeralContainers {
+		spec.EphemeralContainers = podSpec.EphemeralContainers[i : i+1]
+		if failsFor(&spec) {
+			containers = append(containers, podSpec.EphemeralContainers[i].Name)
+		}
+	}
+	return containers
+}
@@ -0,0 +1,62 @@
+package admission
+
+import (
+	psadmission "k8s.io/pod-security-admission/admission"
+	psadmissionapi "k8s.io/pod-security-admission/admission/api"
+	psapi "k8s.io/pod-security-admission/api"
+	"k8s.io/utils/strings/slices"
+)
+
+// Exemption is the reason an object is exempt from the PodSecurity evaluation
+type Exemption string
+
+const (
+	ExemptNamespace    Exemption = "namespace"
+	ExemptUser         Exemption = "user"
+	ExemptRuntimeClass Exemption = "runtimeClass"
+)
+
+// exemptionFor returns the reason the object is exempt or an empty string if
+// it is not. The responses of the PodSecurity admission do not tell exempt
+// objects apart from the allowed ones so this follows the admission logic.
+func exemptionFor(exemptions psadmissionapi.PodSecurityExemptions, podSpecExtractor psadmission.PodSpecExtractor, attrs psapi.Attributes) Exemption {
+	if slices.Contains(exemptions.Namespaces, attrs.GetNamespace()) {
+		return ExemptNamespace
+	}
+	if slices.Contains(exemptions.Usernames, attrs.GetUserName()) {
+		return ExemptUser
+	}
+
+	obj, err := attrs.GetObject()
+	if err != nil {
+		return ""
+	}
+	_, podSpec, err := podSpecExtractor.ExtractPodSpec(obj)
+	if err != nil || podSpec == nil || podSpec.RuntimeClassName == nil {
+		return ""
+	}
+	if slices.Contains(exemptions.RuntimeClasses, *podSpec.RuntimeClassName) {
+		return ExemptRuntimeClass
+	}
+	return ""
+}
+
+// MergeExemptions returns the exemptions of both, e.g. of the flags and of
+// the PodSecurity configuration of the cluster
+func MergeExemptions(a, b psadmissionapi.PodSecurityExemptions) psadmissionapi.PodSecurityExemptions {
+	return psadmissionapi.PodSecurityExemptions{
+		Usernames:      mergeStrings(a.Usernames, b.Usernames),
+		Namespaces:     mergeStrings(a.Namespaces, b.Namespaces),
+		RuntimeClasses: mergeStrings(a.RuntimeClasses, b.RuntimeClasses),
+	}
+}
+
+func mergeStrings(a, b []string) []string {
+	merged := append([]string{}, a...)
+	for _, s := range b {
+		if !slices.Contains(merged, s) {
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
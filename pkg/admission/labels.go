@@ -0,0 +1,100 @@
+package admission
+
+import (
+	psadmissionapi "k8s.io/pod-security-admission/admission/api"
+	psapi "k8s.io/pod-security-admission/api"
+)
+
+// LabelStatus describes how the current enforce label of a namespace compares
+// to the level computed for its workloads
+type LabelStatus string
+
+const (
+	LabelMatches LabelStatus = "matches"
+	LabelMissing LabelStatus = "missing"
+	LabelInvalid LabelStatus = "invalid"
+	// LabelTooRestrictive means that some of the workloads would get rejected by the current label
+	LabelTooRestrictive LabelStatus = "tooRestrictive"
+	// LabelTooPermissive means that the namespace label could be tightened
+	LabelTooPermissive LabelStatus = "tooPermissive"
+)
+
+// CompareLabels records the current enforce level of the namespace from its
+// labels and how it compares to the computed level
+func (r *NamespaceResult) CompareLabels(nsLabels map[string]string) {
+	label, ok := nsLabels[psapi.EnforceLevelLabel]
+	if !ok {
+		r.CurrentLevel = nil
+		r.LabelStatus = LabelMissing
+		return
+	}
+	currentLevel := LevelValue(psapi.Level(label))
+	r.CurrentLevel = &currentLevel
+
+	switch {
+	case !currentLevel.Valid():
+		r.LabelStatus = LabelInvalid
+	case currentLevel == r.Level:
+		r.LabelStatus = LabelMatches
+	case r.Level < currentLevel:
+		r.LabelStatus = LabelTooRestrictive
+	default:
+		r.LabelStatus = LabelTooPermissive
+	}
+}
+
+// MarkRejectedObjects flags the objects that the current enforce label of
+// the namespace already rejects
+func (r *NamespaceResult) MarkRejectedObjects(nsLabels map[string]string) {
+	currentLevel := LevelValue(psapi.Level(nsLabels[psapi.EnforceLevelLabel]))
+	if !currentLevel.Valid() {
+		// missing or invalid labels don't enforce anything stricter than privileged
+		return
+	}
+
+	for _, obj := range r.Objects {
+		obj.RejectedByCurrentLabel = obj.Result.Level() < currentLevel
+	}
+}
+
+// RecordAuditAnnotations sets the audit annotations the admission would
+// record for each of the objects at the audit level of the namespace labels
+func (r *NamespaceResult) RecordAuditAnnotations(nsLabels map[string]string) {
+	auditLevel := psapi.Level(nsLabels[psapi.AuditLevelLabel])
+	if !auditLevel.Valid() {
+		// missing or invalid labels audit at the privileged level, nothing is recorded
+		return
+	}
+
+	for _, obj := range r.Objects {
+		obj.AuditAnnotations = obj.Result.auditAnnotations(auditLevel)
+	}
+}
+
+// WithDefaultLabels returns the labels of a namespace with the PodSecurity
+// labels it does not set filled in from the defaults of the PodSecurity
+// configuration, the admission treats the namespace the same way. The labels
+// are returned as they are if there are no defaults.
+func WithDefaultLabels(nsLabels map[string]string, defaults *psadmissionapi.PodSecurityDefaults) map[string]string {
+	if defaults == nil {
+		return nsLabels
+	}
+
+	labels := make(map[string]string, len(nsLabels)+6)
+	for k, v := range nsLabels {
+		labels[k] = v
+	}
+	for label, value := range map[string]string{
+		psapi.EnforceLevelLabel:   defaults.Enforce,
+		psapi.EnforceVersionLabel: defaults.EnforceVersion,
+		psapi.WarnLevelLabel:      defaults.Warn,
+		psapi.WarnVersionLabel:    defaults.WarnVersion,
+		psapi.AuditLevelLabel:     defaults.Audit,
+		psapi.AuditVersionLabel:   defaults.AuditVersion,
+	} {
+		if _, ok := labels[label]; !ok && len(value) > 0 {
+			labels[label] = value
+		}
+	}
+	return labels
+}
@@ -0,0 +1,81 @@
+package admission
+
+import (
+	"encoding/json"
+
+	psapi "k8s.io/pod-security-admission/api"
+)
+
+// Level is a PodSecurity level that can be compared to the other levels,
+// more restrictive levels have higher values
+type Level int
+
+// The values of the levels are also used in metrics. These must stay stable
+// as dashboards rely on them.
+const (
+	LevelUnknownValue    Level = -1
+	LevelPrivilegedValue Level = 0
+	LevelBaselineValue   Level = 1
+	LevelRestrictedValue Level = 2
+)
+
+// LevelValue returns the comparable value of the PodSecurity level, unknown
+// levels are considered the most privileged
+func LevelValue(level psapi.Level) Level {
+	switch level {
+	case psapi.LevelPrivileged:
+		return LevelPrivilegedValue
+	case psapi.LevelBaseline:
+		return LevelBaselineValue
+	case psapi.LevelRestricted:
+		return LevelRestrictedValue
+	default:
+		return LevelUnknownValue
+	}
+}
+
+// ParseLevel parses the name of a PodSecurity level
+func ParseLevel(level string) (Level, error) {
+	psaLevel, err := psapi.ParseLevel(level)
+	if err != nil {
+		return LevelUnknownValue, err
+	}
+	return LevelValue(psaLevel), nil
+}
+
+// PSALevel returns the PodSecurity API level, LevelUnknown for unknown levels
+func (l Level) PSALevel() psapi.Level {
+	switch l {
+	case LevelPrivilegedValue:
+		return psapi.LevelPrivileged
+	case LevelBaselineValue:
+		return psapi.LevelBaseline
+	case LevelRestrictedValue:
+		return psapi.LevelRestricted
+	default:
+		return LevelUnknown
+	}
+}
+
+// Valid returns whether the level is one of the PodSecurity levels
+func (l Level) Valid() bool {
+	return l >= LevelPrivilegedValue && l <= LevelRestrictedValue
+}
+
+func (l Level) String() string {
+	return string(l.PSALevel())
+}
+
+// MarshalJSON keeps the levels human-readable in the output
+func (l Level) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
+
+func (l *Level) UnmarshalJSON(data []byte) error {
+	var level string
+	if err := json.Unmarshal(data, &level); err != nil {
+		return err
+	}
+	*l = LevelValue(psapi.Level(level))
+	return nil
+}
@@ -0,0 +1,70 @@
+package admission
+
+import (
+	"fmt"
+
+	psapi "k8s.io/pod-security-admission/api"
+)
+
+// Mode is a PodSecurity admission mode
+type Mode string
+
+const (
+	ModeEnforce Mode = "enforce"
+	ModeWarn    Mode = "warn"
+	ModeAudit   Mode = "audit"
+)
+
+var Modes = []Mode{ModeEnforce, ModeWarn, ModeAudit}
+
+func ParseModes(modes []string) ([]Mode, error) {
+	ret := make([]Mode, 0, len(modes))
+	for _, m := range modes {
+		switch mode := Mode(m); mode {
+		case ModeEnforce, ModeWarn, ModeAudit:
+			ret = append(ret, mode)
+		default:
+			return nil, fmt.Errorf("unknown mode %q, must be one of %v", m, Modes)
+		}
+	}
+	return ret, nil
+}
+
+// PolicyVersions are the PodSecurity policy versions each of the modes evaluates against
+type PolicyVersions struct {
+	Enforce, Warn, Audit psapi.Version
+}
+
+// levelForFailedChecks returns the most restrictive level that allows an object
+// which failed the given checks
+func levelForFailedChecks(failed []FailedCheck) Level {
+	level := LevelRestrictedValue
+	for _, check := range failed {
+		if check.RequiredLevel < level {
+			level = check.RequiredLevel
+		}
+	}
+	return level
+}
+
+func (r *ParallelAdmissionResult) LevelForMode(mode Mode) Level {
+	switch mode {
+	case ModeWarn:
+		return r.WarnLevel
+	case ModeAudit:
+		return r.AuditLevel
+	default:
+		return r.Level()
+	}
+}
+
+func (r *NamespaceResult) LevelForMode(mode Mode) Level {
+	switch mode {
+	case ModeWarn:
+		return r.WarnLevel
+	case ModeAudit:
+		return r.AuditLevel
+	default:
+		return r.Level
+	}
+}
@@ -0,0 +1,53 @@
+package admission
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	psadmission "k8s.io/pod-security-admission/admission"
+
+	"k8s.io/klog/v2"
+)
+
+// cachingNamespaceGetter memoizes the namespaces retrieved by the wrapped getter,
+// failed retrievals are not cached
+type cachingNamespaceGetter struct {
+	delegate psadmission.NamespaceGetter
+
+	lock  sync.Mutex
+	cache map[string]*corev1.Namespace
+}
+
+var _ psadmission.NamespaceGetter = &cachingNamespaceGetter{}
+
+// NewCachingNamespaceGetter returns a NamespaceGetter that only asks the delegate
+// for each namespace once during its lifetime
+func NewCachingNamespaceGetter(delegate psadmission.NamespaceGetter) psadmission.NamespaceGetter {
+	return &cachingNamespaceGetter{
+		delegate: delegate,
+		cache:    make(map[string]*corev1.Namespace),
+	}
+}
+
+func (g *cachingNamespaceGetter) GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
+	g.lock.Lock()
+	ns, ok := g.cache[name]
+	g.lock.Unlock()
+	if ok {
+		klog.V(5).InfoS("Using the cached namespace", "namespace", name)
+		return ns, nil
+	}
+
+	klog.V(4).InfoS("Retrieving the namespace", "namespace", name)
+	ns, err := g.delegate.GetNamespace(ctx, name)
+	if err != nil {
+		klog.V(2).InfoS("Failed to retrieve the namespace", "namespace", name, "err", err)
+		return nil, err
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.cache[name] = ns
+	return ns, nil
+}
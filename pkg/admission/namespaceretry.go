@@ -0,0 +1,38 @@
+package admission
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	psadmission "k8s.io/pod-security-admission/admission"
+
+	"github.com/stlaz/psachecker/pkg/apiretry"
+)
+
+// retryingNamespaceGetter retries the retrievals of the wrapped getter that
+// failed with transient errors
+type retryingNamespaceGetter struct {
+	delegate   psadmission.NamespaceGetter
+	maxRetries int
+}
+
+var _ psadmission.NamespaceGetter = &retryingNamespaceGetter{}
+
+// NewRetryingNamespaceGetter returns a NamespaceGetter that retries each
+// retrieval up to maxRetries times
+func NewRetryingNamespaceGetter(delegate psadmission.NamespaceGetter, maxRetries int) psadmission.NamespaceGetter {
+	return &retryingNamespaceGetter{
+		delegate:   delegate,
+		maxRetries: maxRetries,
+	}
+}
+
+func (g *retryingNamespaceGetter) GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
+	var ns *corev1.Namespace
+	err := apiretry.OnError(g.maxRetries, func() error {
+		var err error
+		ns, err = g.delegate.GetNamespace(ctx, name)
+		return err
+	})
+	return ns, err
+}
@@ -0,0 +1,95 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// OrderedNamespaceResultsMap holds the results of the namespaces, its Keys()
+// are in the same order on every call and run regardless of the map iteration
+type OrderedNamespaceResultsMap struct {
+	ordered     bool
+	internalMap map[string]*NamespaceResult
+	keys        sort.StringSlice
+	// less orders the keys instead of their names if set
+	less func(a, b string) bool
+}
+
+func NewOrderedNamespaceResultsMap(m map[string]*NamespaceResult) *OrderedNamespaceResultsMap {
+	ret := &OrderedNamespaceResultsMap{
+		ordered:     true,
+		internalMap: make(map[string]*NamespaceResult),
+		keys:        make([]string, 0),
+	}
+
+	if len(m) != 0 {
+		ret.ordered = false
+		ret.internalMap = m
+		for k := range m {
+			ret.keys = append(ret.keys, k)
+		}
+	}
+
+	return ret
+}
+
+func (m *OrderedNamespaceResultsMap) Set(k string, v *NamespaceResult) {
+	if _, ok := m.internalMap[k]; !ok {
+		m.ordered = false
+		m.keys = append(m.keys, k)
+	}
+	m.internalMap[k] = v
+}
+
+func (m *OrderedNamespaceResultsMap) Get(k string) *NamespaceResult {
+	return m.internalMap[k]
+}
+
+func (m *OrderedNamespaceResultsMap) Keys() []string {
+	ret := make([]string, len(m.keys))
+
+	if !m.ordered {
+		// the keys come from a map, sort them by their names first so that
+		// the ones the less function considers equal keep a stable order
+		m.keys.Sort()
+		if m.less != nil {
+			sort.SliceStable(m.keys, func(i, j int) bool { return m.less(m.keys[i], m.keys[j]) })
+		}
+		m.ordered = true
+	}
+
+	copy(ret, m.keys)
+	return ret
+}
+
+// SortFunc orders the keys by the less function instead of by their names
+func (m *OrderedNamespaceResultsMap) SortFunc(less func(a, b string) bool) {
+	m.less = less
+	m.ordered = false
+}
+
+// MarshalJSON serializes the map as a JSON object keyed by namespace, the
+// namespaces are written in the order of the Keys()
+func (m *OrderedNamespaceResultsMap) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, k := range m.Keys() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.internalMap[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,125 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOrderedNamespaceResultsMapKeys(t *testing.T) {
+	expected := []string{"apps", "default", "kube-system", "monitoring"}
+
+	inserted := NewOrderedNamespaceResultsMap(nil)
+	for _, ns := range []string{"monitoring", "apps", "kube-system", "default"} {
+		inserted.Set(ns, &NamespaceResult{})
+	}
+	reversed := NewOrderedNamespaceResultsMap(nil)
+	for i := len(expected) - 1; i >= 0; i-- {
+		reversed.Set(expected[i], &NamespaceResult{})
+	}
+
+	for _, m := range []*OrderedNamespaceResultsMap{inserted, reversed} {
+		if keys := m.Keys(); !reflect.DeepEqual(keys, expected) {
+			t.Errorf("expected the keys %v, got %v", expected, keys)
+		}
+		// setting the existing keys again does not move them
+		m.Set("monitoring", &NamespaceResult{})
+		if keys := m.Keys(); !reflect.DeepEqual(keys, expected) {
+			t.Errorf("expected the keys %v after setting an existing key, got %v", expected, keys)
+		}
+	}
+
+	inserted.Set("batch", &NamespaceResult{})
+	withBatch := []string{"apps", "batch", "default", "kube-system", "monitoring"}
+	if keys := inserted.Keys(); !reflect.DeepEqual(keys, withBatch) {
+		t.Errorf("expected the keys %v after inserting a new key, got %v", withBatch, keys)
+	}
+
+	// the returned keys are a copy
+	keys := inserted.Keys()
+	keys[0] = "changed"
+	if keys := inserted.Keys(); !reflect.DeepEqual(keys, withBatch) {
+		t.Errorf("expected the keys %v after modifying the returned ones, got %v", withBatch, keys)
+	}
+}
+
+func TestOrderedNamespaceResultsMapSortFuncTies(t *testing.T) {
+	results := map[string]*NamespaceResult{
+		"web":        {Level: LevelBaselineValue},
+		"db":         {Level: LevelPrivilegedValue},
+		"api":        {Level: LevelBaselineValue},
+		"monitoring": {Level: LevelPrivilegedValue},
+		"docs":       {Level: LevelRestrictedValue},
+		"cache":      {Level: LevelBaselineValue},
+	}
+	// the ties are broken by the names of the namespaces
+	expected := []string{"db", "monitoring", "api", "cache", "web", "docs"}
+
+	// the keys of maps built from maps come in the random order of the map iteration
+	for i := 0; i < 20; i++ {
+		m := NewOrderedNamespaceResultsMap(results)
+		m.SortFunc(func(a, b string) bool { return m.Get(a).Level < m.Get(b).Level })
+
+		if keys := m.Keys(); !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("expected the keys %v, got %v", expected, keys)
+		}
+		if keys := m.Keys(); !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("expected the keys %v on the second call, got %v", expected, keys)
+		}
+	}
+}
+
+func TestOrderedNamespaceResultsMapMarshalJSON(t *testing.T) {
+	m := NewOrderedNamespaceResultsMap(map[string]*NamespaceResult{
+		"web":  {Level: LevelBaselineValue},
+		"db":   {Level: LevelPrivilegedValue},
+		"docs": {Level: LevelRestrictedValue},
+	})
+	m.SortFunc(func(a, b string) bool { return m.Get(a).Level < m.Get(b).Level })
+	expected := []string{"db", "web", "docs"}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("failed to marshal the results: %v", err)
+	}
+
+	// the keys of the decoded maps would lose the order, the tokens keep it
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		t.Fatalf("failed to decode %s: %v", data, err)
+	}
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			t.Fatalf("failed to decode %s: %v", data, err)
+		}
+		keys = append(keys, token.(string))
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			t.Fatalf("failed to decode the result of %s: %v", token, err)
+		}
+	}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected the namespaces in the order %v, got %v", expected, keys)
+	}
+
+	var decoded map[string]*NamespaceResult
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", data, err)
+	}
+	for _, ns := range expected {
+		if decoded[ns] == nil || decoded[ns].Level != m.Get(ns).Level {
+			t.Errorf("expected %s to be %s, got %v", ns, m.Get(ns).Level, decoded[ns])
+		}
+	}
+
+	empty, err := json.Marshal(NewOrderedNamespaceResultsMap(nil))
+	if err != nil {
+		t.Fatalf("failed to marshal the empty results: %v", err)
+	}
+	if string(empty) != "{}" {
+		t.Errorf("expected the empty results to be {}, got %s", empty)
+	}
+}
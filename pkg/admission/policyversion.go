@@ -0,0 +1,57 @@
+package admission
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/apiretry"
+)
+
+// PolicyVersionAuto is the policy version that resolves to the version of the API server
+const PolicyVersionAuto = "auto"
+
+// ParsePolicyVersion parses the policy version, PolicyVersionAuto resolves
+// to the version of the server or to the latest one if server is nil, e.g.
+// when evaluating local files. The returned bool is true if the version was
+// resolved from the server.
+func ParsePolicyVersion(value string, server discovery.ServerVersionInterface, maxRetries int) (psapi.Version, bool, error) {
+	if value != PolicyVersionAuto {
+		v, err := psapi.ParseVersion(value)
+		return v, false, err
+	}
+	if server == nil {
+		return psapi.LatestVersion(), false, nil
+	}
+
+	var info *version.Info
+	err := apiretry.OnError(maxRetries, func() error {
+		var err error
+		info, err = server.ServerVersion()
+		return err
+	})
+	if err != nil {
+		return psapi.Version{}, false, fmt.Errorf("failed to retrieve the version of the server: %w", err)
+	}
+
+	v, err := serverPolicyVersion(info)
+	return v, err == nil, err
+}
+
+// serverPolicyVersion converts the version of the server to the policy
+// version, the providers may suffix the minor version, e.g. "23+"
+func serverPolicyVersion(info *version.Info) (psapi.Version, error) {
+	major, err := strconv.Atoi(info.Major)
+	if err != nil {
+		return psapi.Version{}, fmt.Errorf("unexpected major version of the server %q", info.Major)
+	}
+	minor, err := strconv.Atoi(strings.TrimRight(info.Minor, "+"))
+	if err != nil {
+		return psapi.Version{}, fmt.Errorf("unexpected minor version of the server %q", info.Minor)
+	}
+	return psapi.MajorMinorVersion(major, minor), nil
+}
@@ -0,0 +1,270 @@
+package admission
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	psapi "k8s.io/pod-security-admission/api"
+)
+
+// NamespaceResult is the least privileged PodSecurity level that still allows
+// all the evaluated objects of a namespace to run, along with the objects
+// that were considered
+type NamespaceResult struct {
+	Level   Level           `json:"level"`
+	Objects []*ObjectResult `json:"objects"`
+
+	// WarnLevel and AuditLevel are the least privileged levels at which none
+	// of the objects trigger warnings and audit annotations, respectively
+	WarnLevel  Level `json:"warnLevel"`
+	AuditLevel Level `json:"auditLevel"`
+
+	// RequiredBy lists the "Kind/name" of the objects that require the level,
+	// it is empty if the level is restricted
+	RequiredBy []string `json:"requiredBy,omitempty"`
+	// OthersLevel is the level the other objects of the namespace require,
+	// it is only set if there are other objects requiring a less privileged
+	// level, i.e. the RequiredBy objects block the namespace from being tightened
+	OthersLevel *Level `json:"othersLevel,omitempty"`
+
+	// PolicyVersion is the version pinned by the live namespace that the
+	// objects were evaluated against, only set if it differs from the global one
+	PolicyVersion string `json:"policyVersion,omitempty"`
+
+	// CurrentLevel and LabelStatus are only set when the results were
+	// compared to the enforce label of the live namespace, CurrentLevel is
+	// unknown if the label is invalid and not set if it is missing
+	CurrentLevel *Level      `json:"currentLevel,omitempty"`
+	LabelStatus  LabelStatus `json:"labelStatus,omitempty"`
+
+	// WaivedChecks are the IDs of the ignored PodSecurity checks that some of
+	// the objects did not pass, the levels would be more privileged without the waivers
+	WaivedChecks []string `json:"waivedChecks,omitempty"`
+
+	// NamespaceExists is only set when the namespace was looked up in the
+	// cluster, missing namespaces are treated as unlabeled
+	NamespaceExists *bool `json:"namespaceExists,omitempty"`
+
+	// SCCs are the OpenShift SecurityContextConstraints the pods of the
+	// namespace were admitted by, only set when requested
+	SCCs []string `json:"sccs,omitempty"`
+
+	// LevelsByVersion are the levels required under each of the policy
+	// versions, only set when several versions were compared
+	LevelsByVersion map[string]Level `json:"levelsByVersion,omitempty"`
+}
+
+// ObjectResult identifies an evaluated object and holds its admission results
+type ObjectResult struct {
+	APIVersion string                   `json:"apiVersion"`
+	Kind       string                   `json:"kind"`
+	Namespace  string                   `json:"namespace"`
+	Name       string                   `json:"name"`
+	Result     *ParallelAdmissionResult `json:"result"`
+
+	// RejectedByCurrentLabel is set if the current enforce label of the live
+	// namespace does not allow the object
+	RejectedByCurrentLabel bool `json:"rejectedByCurrentLabel,omitempty"`
+
+	// ResolvedFrom lists the "Kind/name" of the pods that were evaluated as
+	// this object, their top controller, or of the Jobs of this CronJob
+	ResolvedFrom []string `json:"resolvedFrom,omitempty"`
+
+	// StaticPod is set for the mirror pods of static pods, the kubelet runs
+	// them regardless of the labels of their namespace, their manifests on
+	// the nodes need to be fixed instead
+	StaticPod bool `json:"staticPod,omitempty"`
+
+	// AuditAnnotations are the annotations the PodSecurity admission would
+	// record in the audit log for the object at the current audit level of
+	// its live namespace, e.g. the audit violations
+	AuditAnnotations map[string]string `json:"auditAnnotations,omitempty"`
+
+	// DeprecatedAPI warns about the deprecated or removed API version the
+	// object was submitted under, it was evaluated as its replacement
+	DeprecatedAPI string `json:"deprecatedAPI,omitempty"`
+
+	// MeetsTargetLevel and RequiredChanges are only set when the results were
+	// compared to a target level, RequiredChanges are the failed checks that
+	// keep the object from meeting it
+	MeetsTargetLevel *bool         `json:"meetsTargetLevel,omitempty"`
+	RequiredChanges  []FailedCheck `json:"requiredChanges,omitempty"`
+
+	// LevelsByVersion are the levels the object requires under each of the
+	// policy versions, only set when several versions were compared
+	LevelsByVersion map[string]Level `json:"levelsByVersion,omitempty"`
+
+	// Raw are the responses of the PodSecurity admissions of each level as
+	// they were returned, only set when requested, see IncludeRawResponses
+	Raw *RawAdmissionResponses `json:"raw,omitempty"`
+}
+
+// RawAdmissionResponses are the unmodified responses of the PodSecurity
+// admission library, along with their warnings, audit annotations and statuses
+type RawAdmissionResponses struct {
+	Privileged *admissionv1.AdmissionResponse `json:"privileged"`
+	Baseline   *admissionv1.AdmissionResponse `json:"baseline"`
+	Restricted *admissionv1.AdmissionResponse `json:"restricted"`
+}
+
+// IncludeRawResponses attaches the raw admission responses to each of the
+// objects of the results
+func IncludeRawResponses(results *OrderedNamespaceResultsMap) {
+	for _, ns := range results.Keys() {
+		for _, obj := range results.Get(ns).Objects {
+			obj.Raw = &RawAdmissionResponses{
+				Privileged: obj.Result.Privileged,
+				Baseline:   obj.Result.Baseline,
+				Restricted: obj.Result.Restricted,
+			}
+		}
+	}
+}
+
+func AggregateResultsPerNamespace(results AdmissionResultsMap) map[string]*NamespaceResult {
+	aggregatedResults := make(map[string]*NamespaceResult)
+	// the objects are mostly of a handful of kinds, their apiVersions are only built once
+	apiVersions := map[schema.GroupVersion]string{}
+	for objInfo, result := range results {
+		level := result.Level()
+		nsResult, ok := aggregatedResults[objInfo.Namespace]
+		if !ok {
+			nsResult = &NamespaceResult{
+				Level:      level,
+				WarnLevel:  result.WarnLevel,
+				AuditLevel: result.AuditLevel,
+			}
+			aggregatedResults[objInfo.Namespace] = nsResult
+		} else {
+			nsResult.Level = greaterPrivileges(nsResult.Level, level)
+			nsResult.WarnLevel = greaterPrivileges(nsResult.WarnLevel, result.WarnLevel)
+			nsResult.AuditLevel = greaterPrivileges(nsResult.AuditLevel, result.AuditLevel)
+		}
+
+		gv := objInfo.GVK.GroupVersion()
+		apiVersion, ok := apiVersions[gv]
+		if !ok {
+			apiVersion = gv.String()
+			apiVersions[gv] = apiVersion
+		}
+		nsResult.Objects = append(nsResult.Objects, &ObjectResult{
+			APIVersion: apiVersion,
+			Kind:       objInfo.GVK.Kind,
+			Namespace:  objInfo.Namespace,
+			Name:       objInfo.Name,
+			Result:     result,
+		})
+	}
+
+	// map iteration is random, keep the objects sorted so that the output is stable
+	for _, nsResult := range aggregatedResults {
+		sort.Slice(nsResult.Objects, func(i, j int) bool {
+			a, b := nsResult.Objects[i], nsResult.Objects[j]
+			if a.Kind != b.Kind {
+				return a.Kind < b.Kind
+			}
+			if a.APIVersion != b.APIVersion {
+				return a.APIVersion < b.APIVersion
+			}
+			return a.Name < b.Name
+		})
+
+		waived := map[string]bool{}
+		for _, obj := range nsResult.Objects {
+			for _, check := range obj.Result.WaivedChecks {
+				if !waived[check.ID] {
+					waived[check.ID] = true
+					nsResult.WaivedChecks = append(nsResult.WaivedChecks, check.ID)
+				}
+			}
+		}
+		sort.Strings(nsResult.WaivedChecks)
+
+		if nsResult.Level == LevelRestrictedValue {
+			continue
+		}
+		var othersLevel *Level
+		for _, obj := range nsResult.Objects {
+			if level := obj.Result.Level(); level == nsResult.Level {
+				nsResult.RequiredBy = append(nsResult.RequiredBy, obj.Kind+"/"+obj.Name)
+			} else if othersLevel == nil {
+				othersLevel = &level
+			} else {
+				*othersLevel = greaterPrivileges(*othersLevel, level)
+			}
+		}
+		nsResult.OthersLevel = othersLevel
+	}
+
+	return aggregatedResults
+}
+
+// PolicyError is returned when the results fail a policy gate, e.g. the
+// --max-level, as opposed to the errors of the evaluation itself
+type PolicyError struct {
+	Err error
+}
+
+func (e *PolicyError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PolicyError) Unwrap() error {
+	return e.Err
+}
+
+// CheckMaxLevel returns a PolicyError listing the namespaces that require a
+// more privileged level than maxLevel
+func CheckMaxLevel(results *OrderedNamespaceResultsMap, maxLevel psapi.Level) error {
+	var offending []string
+	for _, ns := range results.Keys() {
+		if level := results.Get(ns).Level; level < LevelValue(maxLevel) {
+			offending = append(offending, fmt.Sprintf("%s (%s)", ns, level))
+		}
+	}
+
+	if len(offending) > 0 {
+		return &PolicyError{Err: fmt.Errorf("namespaces require a more privileged level than %q: %s", maxLevel, strings.Join(offending, ", "))}
+	}
+	return nil
+}
+
+// DeniedObject is an object that the PodSecurity admission would deny at a given level
+type DeniedObject struct {
+	*ObjectResult
+	// Message is the reason of the denial as reported by the admission
+	Message string
+}
+
+// DeniedObjects returns the objects, in the order of the namespaces, that
+// would be denied if their namespace enforced the target level
+func DeniedObjects(results *OrderedNamespaceResultsMap, target psapi.Level) []*DeniedObject {
+	var denied []*DeniedObject
+	for _, ns := range results.Keys() {
+		for _, obj := range results.Get(ns).Objects {
+			if obj.Result.Level() >= LevelValue(target) {
+				continue
+			}
+			denied = append(denied, &DeniedObject{
+				ObjectResult: obj,
+				Message:      obj.Result.messageForLevel(target),
+			})
+		}
+	}
+	return denied
+}
+
+// WithoutCompliant returns a copy of the results without the namespaces whose
+// level is not more privileged than the target level
+func WithoutCompliant(results *OrderedNamespaceResultsMap, target psapi.Level) *OrderedNamespaceResultsMap {
+	filtered := NewOrderedNamespaceResultsMap(nil)
+	for _, ns := range results.Keys() {
+		if nsResult := results.Get(ns); nsResult.Level < LevelValue(target) {
+			filtered.Set(ns, nsResult)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,21 @@
+package admission
+
+import (
+	"context"
+	"testing"
+)
+
+func BenchmarkAggregateResultsPerNamespace(b *testing.B) {
+	results, err := newBenchmarkAdmission(b).ValidateResources(context.Background(), true, nil, benchmarkInfos()...)
+	if err != nil {
+		b.Fatalf("failed to validate the resources: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if nsResults := AggregateResultsPerNamespace(results); len(nsResults) != benchmarkNamespaces {
+			b.Fatalf("expected the results of %d namespaces, got %d", benchmarkNamespaces, len(nsResults))
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package admission
+
+import (
+	psapi "k8s.io/pod-security-admission/api"
+)
+
+// CompareToTargetLevel sets whether each of the objects meets the target
+// level, and the failed checks that have to be fixed for it if it does not
+func (r *NamespaceResult) CompareToTargetLevel(target psapi.Level) {
+	for _, obj := range r.Objects {
+		meets := obj.Result.Level() >= LevelValue(target)
+		obj.MeetsTargetLevel = &meets
+
+		obj.RequiredChanges = nil
+		if meets {
+			continue
+		}
+		for _, check := range obj.Result.FailedChecks {
+			// the checks of the more restrictive levels don't matter at the target
+			if check.RequiredLevel < LevelValue(target) {
+				obj.RequiredChanges = append(obj.RequiredChanges, check)
+			}
+		}
+	}
+}
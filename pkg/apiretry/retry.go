@@ -0,0 +1,47 @@
+// Package apiretry retries the requests to the API server that failed with
+// transient errors
+package apiretry
+
+import (
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// IsRetriable returns true for the errors that are likely to go away when
+// the request is repeated, e.g. throttling by the API server or a reset connection
+func IsRetriable(err error) bool {
+	return apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err)
+}
+
+// OnError calls fn until it succeeds, fails with an error that is not
+// retriable or was retried maxRetries times, backing off exponentially
+// between the attempts
+func OnError(maxRetries int, fn func() error) error {
+	backoff := wait.Backoff{
+		Steps:    maxRetries + 1,
+		Duration: 200 * time.Millisecond,
+		Factor:   2.0,
+		Jitter:   0.1,
+		Cap:      10 * time.Second,
+	}
+
+	attempt := 0
+	return retry.OnError(backoff, IsRetriable, func() error {
+		attempt++
+		err := fn()
+		if err != nil && IsRetriable(err) && attempt <= maxRetries {
+			klog.V(2).InfoS("Retrying after a transient error", "attempt", attempt, "err", err)
+		}
+		return err
+	})
+}
@@ -0,0 +1,90 @@
+package checkdocs
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	psapi "k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+func NewExplainCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:          "explain [CHECK]",
+		Short:        "describe what a PodSecurity check inspects and how to pass it, or list the checks",
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			checks := policy.DefaultChecks()
+			if len(args) == 0 {
+				printChecks(c.OutOrStdout(), checks)
+				return nil
+			}
+
+			known := make([]string, 0, len(checks))
+			for _, check := range checks {
+				if check.ID == args[0] {
+					printCheck(c.OutOrStdout(), check)
+					return nil
+				}
+				known = append(known, check.ID)
+			}
+			return fmt.Errorf("unknown PodSecurity check %q, must be any of: %s", args[0], strings.Join(known, ", "))
+		},
+	}
+}
+
+func printChecks(w io.Writer, checks []policy.Check) {
+	for _, check := range checks {
+		fmt.Fprintf(w, "%s (%s): %s\n", check.ID, check.Level, checkDocs[check.ID].description)
+	}
+}
+
+func printCheck(w io.Writer, check policy.Check) {
+	fmt.Fprintf(w, "%s\n", check.ID)
+	fmt.Fprintf(w, "  Level:    %s, the objects that fail it require %s\n", check.Level, requiredLevel(check.Level))
+	fmt.Fprintf(w, "  Versions: %s\n", describeVersions(check.Versions))
+
+	doc, ok := checkDocs[check.ID]
+	if !ok {
+		// a check added to the admission library that is not described yet
+		fmt.Fprintf(w, "\n  See https://kubernetes.io/docs/concepts/security/pod-security-standards/\n")
+		return
+	}
+	fmt.Fprintf(w, "\n  %s\n", doc.description)
+	fmt.Fprintf(w, "\n  Fields:\n")
+	for _, field := range doc.fields {
+		fmt.Fprintf(w, "    %s\n", field)
+	}
+	fmt.Fprintf(w, "\n  Allowed values: %s\n", doc.allowed)
+	fmt.Fprintf(w, "\n  Example:\n")
+	for _, line := range strings.Split(doc.example, "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
+
+// requiredLevel is the most restrictive level that does not include a check of the level
+func requiredLevel(level psapi.Level) psapi.Level {
+	if level == psapi.LevelRestricted {
+		return psapi.LevelBaseline
+	}
+	return psapi.LevelPrivileged
+}
+
+func describeVersions(versions []policy.VersionedCheck) string {
+	if len(versions) == 0 || versions[0].MinimumVersion == (psapi.Version{}) {
+		return "not assigned to a policy version yet"
+	}
+
+	description := fmt.Sprintf("since %s", versions[0].MinimumVersion)
+	if len(versions) > 1 {
+		revisions := make([]string, 0, len(versions)-1)
+		for _, v := range versions[1:] {
+			revisions = append(revisions, v.MinimumVersion.String())
+		}
+		description += fmt.Sprintf(", revised in %s", strings.Join(revisions, ", "))
+	}
+	return description
+}
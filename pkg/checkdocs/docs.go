@@ -0,0 +1,248 @@
+package checkdocs
+
+// checkDoc describes a PodSecurity check, the admission library only carries
+// the IDs, levels and versions of the checks
+type checkDoc struct {
+	description string
+	// fields are the paths of the pod fields the check inspects, the pod
+	// templates of the workloads are checked at the same paths
+	fields  []string
+	allowed string
+	// example is a pod snippet that passes the check
+	example string
+}
+
+var checkDocs = map[string]checkDoc{
+	"allowPrivilegeEscalation": {
+		description: "Privilege escalation, such as via set-user-ID or set-group-ID file mode, must not be allowed.",
+		fields: []string{
+			"spec.containers[*].securityContext.allowPrivilegeEscalation",
+			"spec.initContainers[*].securityContext.allowPrivilegeEscalation",
+			"spec.ephemeralContainers[*].securityContext.allowPrivilegeEscalation",
+		},
+		allowed: "false",
+		example: `spec:
+  containers:
+  - name: app
+    securityContext:
+      allowPrivilegeEscalation: false`,
+	},
+	"appArmorProfile": {
+		description: "The default AppArmor profile must not be overridden or disabled, only the runtime default and the localhost profiles are allowed.",
+		fields: []string{
+			"metadata.annotations['container.apparmor.security.beta.kubernetes.io/*']",
+		},
+		allowed: `undefined, "runtime/default", "localhost/*"`,
+		example: `metadata:
+  annotations:
+    container.apparmor.security.beta.kubernetes.io/app: runtime/default`,
+	},
+	"capabilities_baseline": {
+		description: "Adding NET_RAW or the capabilities beyond the default set of the container runtimes must be disallowed.",
+		fields: []string{
+			"spec.containers[*].securityContext.capabilities.add",
+			"spec.initContainers[*].securityContext.capabilities.add",
+			"spec.ephemeralContainers[*].securityContext.capabilities.add",
+		},
+		allowed: "undefined, AUDIT_WRITE, CHOWN, DAC_OVERRIDE, FOWNER, FSETID, KILL, MKNOD, NET_BIND_SERVICE, SETFCAP, SETGID, SETPCAP, SETUID, SYS_CHROOT",
+		example: `spec:
+  containers:
+  - name: app
+    securityContext:
+      capabilities:
+        add: ["NET_BIND_SERVICE"]`,
+	},
+	"capabilities_restricted": {
+		description: "Containers must drop all the capabilities and may only add NET_BIND_SERVICE back.",
+		fields: []string{
+			"spec.containers[*].securityContext.capabilities.drop",
+			"spec.initContainers[*].securityContext.capabilities.drop",
+			"spec.ephemeralContainers[*].securityContext.capabilities.drop",
+			"spec.containers[*].securityContext.capabilities.add",
+			"spec.initContainers[*].securityContext.capabilities.add",
+			"spec.ephemeralContainers[*].securityContext.capabilities.add",
+		},
+		allowed: `drop must include "ALL", add must be undefined or only "NET_BIND_SERVICE"`,
+		example: `spec:
+  containers:
+  - name: app
+    securityContext:
+      capabilities:
+        drop: ["ALL"]`,
+	},
+	"hostNamespaces": {
+		description: "Sharing the network, PID or IPC namespaces of the host must be disallowed.",
+		fields: []string{
+			"spec.hostNetwork",
+			"spec.hostPID",
+			"spec.hostIPC",
+		},
+		allowed: "undefined, false",
+		example: `spec:
+  hostNetwork: false
+  hostPID: false
+  hostIPC: false`,
+	},
+	"hostPathVolumes": {
+		description: "HostPath volumes must be forbidden.",
+		fields: []string{
+			"spec.volumes[*].hostPath",
+		},
+		allowed: "undefined",
+		example: `spec:
+  volumes:
+  - name: data
+    emptyDir: {}`,
+	},
+	"hostPorts": {
+		description: "Binding the ports of the containers to the ports of the host must be forbidden.",
+		fields: []string{
+			"spec.containers[*].ports[*].hostPort",
+			"spec.initContainers[*].ports[*].hostPort",
+			"spec.ephemeralContainers[*].ports[*].hostPort",
+		},
+		allowed: "undefined, 0",
+		example: `spec:
+  containers:
+  - name: app
+    ports:
+    - containerPort: 8080`,
+	},
+	"privileged": {
+		description: "Privileged containers disable most of the security mechanisms and must be disallowed.",
+		fields: []string{
+			"spec.containers[*].securityContext.privileged",
+			"spec.initContainers[*].securityContext.privileged",
+			"spec.ephemeralContainers[*].securityContext.privileged",
+		},
+		allowed: "undefined, false",
+		example: `spec:
+  containers:
+  - name: app
+    securityContext:
+      privileged: false`,
+	},
+	"procMount": {
+		description: "The default /proc masks reduce the attack surface and must be kept.",
+		fields: []string{
+			"spec.containers[*].securityContext.procMount",
+			"spec.initContainers[*].securityContext.procMount",
+			"spec.ephemeralContainers[*].securityContext.procMount",
+		},
+		allowed: `undefined, "Default"`,
+		example: `spec:
+  containers:
+  - name: app
+    securityContext:
+      procMount: Default`,
+	},
+	"restrictedVolumes": {
+		description: "Only the configMap, downwardAPI, emptyDir, projected, secret, csi, persistentVolumeClaim and ephemeral inline volume sources are allowed.",
+		fields: []string{
+			"spec.volumes[*]",
+		},
+		allowed: "volumes of the listed sources",
+		example: `spec:
+  volumes:
+  - name: config
+    configMap:
+      name: app-config
+  - name: data
+    persistentVolumeClaim:
+      claimName: app-data`,
+	},
+	"runAsNonRoot": {
+		description: "Containers must be required to run as non-root users.",
+		fields: []string{
+			"spec.securityContext.runAsNonRoot",
+			"spec.containers[*].securityContext.runAsNonRoot",
+			"spec.initContainers[*].securityContext.runAsNonRoot",
+			"spec.ephemeralContainers[*].securityContext.runAsNonRoot",
+		},
+		allowed: "true, undefined for the containers if it is true for the pod",
+		example: `spec:
+  securityContext:
+    runAsNonRoot: true`,
+	},
+	"runAsUser": {
+		description: "Containers must not be set to run as the root user.",
+		fields: []string{
+			"spec.securityContext.runAsUser",
+			"spec.containers[*].securityContext.runAsUser",
+			"spec.initContainers[*].securityContext.runAsUser",
+			"spec.ephemeralContainers[*].securityContext.runAsUser",
+		},
+		allowed: "undefined, non-zero values",
+		example: `spec:
+  securityContext:
+    runAsUser: 1000`,
+	},
+	"seLinuxOptions": {
+		description: "Setting the SELinux type is restricted, setting a custom SELinux user or role is forbidden.",
+		fields: []string{
+			"spec.securityContext.seLinuxOptions",
+			"spec.containers[*].securityContext.seLinuxOptions",
+			"spec.initContainers[*].securityContext.seLinuxOptions",
+			"spec.ephemeralContainers[*].securityContext.seLinuxOptions",
+		},
+		allowed: "type undefined, container_t, container_init_t or container_kvm_t; user and role undefined",
+		example: `spec:
+  securityContext:
+    seLinuxOptions:
+      type: container_t`,
+	},
+	"seccompProfile_baseline": {
+		description: "If the seccomp profiles are set, only the runtime default and the localhost profiles are allowed. The policy versions before v1.19 check the seccomp annotations instead.",
+		fields: []string{
+			"spec.securityContext.seccompProfile.type",
+			"spec.containers[*].securityContext.seccompProfile.type",
+			"spec.initContainers[*].securityContext.seccompProfile.type",
+			"spec.ephemeralContainers[*].securityContext.seccompProfile.type",
+		},
+		allowed: `undefined, "RuntimeDefault", "Localhost"`,
+		example: `spec:
+  securityContext:
+    seccompProfile:
+      type: RuntimeDefault`,
+	},
+	"seccompProfile_restricted": {
+		description: "The seccomp profiles must be set, only the runtime default and the localhost profiles are allowed.",
+		fields: []string{
+			"spec.securityContext.seccompProfile.type",
+			"spec.containers[*].securityContext.seccompProfile.type",
+			"spec.initContainers[*].securityContext.seccompProfile.type",
+			"spec.ephemeralContainers[*].securityContext.seccompProfile.type",
+		},
+		allowed: `"RuntimeDefault", "Localhost", undefined for the containers if it is set for the pod`,
+		example: `spec:
+  securityContext:
+    seccompProfile:
+      type: RuntimeDefault`,
+	},
+	"sysctls": {
+		description: "Sysctls can disable security mechanisms or affect all the containers of the host, only the safe subset is allowed.",
+		fields: []string{
+			"spec.securityContext.sysctls[*].name",
+		},
+		allowed: "kernel.shm_rmid_forced, net.ipv4.ip_local_port_range, net.ipv4.tcp_syncookies, net.ipv4.ping_group_range, net.ipv4.ip_unprivileged_port_start",
+		example: `spec:
+  securityContext:
+    sysctls:
+    - name: net.ipv4.ip_local_port_range
+      value: "32768 60999"`,
+	},
+	"windowsHostProcess": {
+		description: "Windows pods and containers must not run as host processes.",
+		fields: []string{
+			"spec.securityContext.windowsOptions.hostProcess",
+			"spec.containers[*].securityContext.windowsOptions.hostProcess",
+			"spec.initContainers[*].securityContext.windowsOptions.hostProcess",
+			"spec.ephemeralContainers[*].securityContext.windowsOptions.hostProcess",
+		},
+		allowed: "undefined, false",
+		example: `spec:
+  securityContext:
+    windowsOptions:
+      hostProcess: false`,
+	},
+}
@@ -0,0 +1,193 @@
+// Package checker evaluates objects against the PodSecurity levels without
+// depending on the command line interface of psachecker
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	psadmission "k8s.io/pod-security-admission/admission"
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+type Options struct {
+	admission.ParallelAdmissionOptions
+
+	// DefaultNamespace is set on the objects that don't have a namespace,
+	// such objects cause an error if it is empty
+	DefaultNamespace string
+
+	// NamespaceGetter looks up the namespaces of the objects, the objects
+	// are evaluated against the policy versions pinned by the VersionLabel of
+	// their namespaces the way the admission does. The objects of the
+	// namespaces that don't exist or don't pin a valid version are evaluated
+	// against the enforce version of the options. If it is nil, all the
+	// namespaces are treated as unlabeled.
+	NamespaceGetter psadmission.NamespaceGetter
+	// VersionLabel is the label of the namespaces that pins their policy
+	// version, psapi.EnforceVersionLabel if it is empty
+	VersionLabel string
+}
+
+// Checker evaluates objects against each of the PodSecurity levels, it can
+// be reused for any number of evaluations
+type Checker struct {
+	kubeClient kubernetes.Interface
+	opts       admission.ParallelAdmissionOptions
+
+	nsGetter     psadmission.NamespaceGetter
+	versionLabel string
+
+	defaultNamespace *string
+
+	// lock guards the admissions, they are set up for each of the pinned
+	// versions the first time an object is evaluated against it
+	lock sync.Mutex
+	adms map[psapi.Version]*admission.ParallelAdmission
+}
+
+func NewChecker(kubeClient kubernetes.Interface, opts *Options) (*Checker, error) {
+	adm, err := admission.NewParallelAdmission(kubeClient, &opts.ParallelAdmissionOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up admission: %w", err)
+	}
+
+	c := &Checker{
+		kubeClient:   kubeClient,
+		opts:         opts.ParallelAdmissionOptions,
+		nsGetter:     opts.NamespaceGetter,
+		versionLabel: opts.VersionLabel,
+		adms:         map[psapi.Version]*admission.ParallelAdmission{opts.PolicyVersions.Enforce: adm},
+	}
+	if len(c.versionLabel) == 0 {
+		c.versionLabel = psapi.EnforceVersionLabel
+	}
+	if len(opts.DefaultNamespace) > 0 {
+		defaultNamespace := opts.DefaultNamespace
+		c.defaultNamespace = &defaultNamespace
+	}
+	return c, nil
+}
+
+// Check evaluates the objects against each of the PodSecurity levels. The
+// objects must have their apiVersion and kind set.
+//
+// The levels are evaluated as if the namespaces of the objects were
+// unlabeled, only the policy versions pinned by the namespaces are taken
+// into account if the options have a NamespaceGetter. The results are keyed
+// by the kind, namespace and name of the objects rather than by a string so
+// that the objects of different kinds with the same name don't collide.
+func Check(ctx context.Context, kubeClient kubernetes.Interface, objects []runtime.Object, opts *Options) (admission.AdmissionResultsMap, error) {
+	c, err := NewChecker(kubeClient, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.Check(ctx, objects)
+}
+
+func (c *Checker) Check(ctx context.Context, objects []runtime.Object) (admission.AdmissionResultsMap, error) {
+	defaultVersion := c.opts.PolicyVersions.Enforce
+	nsVersions := map[string]psapi.Version{}
+	var versions []psapi.Version
+	infosByVersion := map[psapi.Version][]*resource.Info{}
+	for _, obj := range objects {
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, fmt.Errorf("cannot evaluate %T: %w", obj, err)
+		}
+
+		version := defaultVersion
+		if ns := c.namespaceOf(objMeta.GetNamespace()); c.nsGetter != nil && len(ns) > 0 {
+			var ok bool
+			if version, ok = nsVersions[ns]; !ok {
+				if version, err = c.namespacePolicyVersion(ctx, ns); err != nil {
+					return nil, err
+				}
+				nsVersions[ns] = version
+			}
+		}
+
+		if _, ok := infosByVersion[version]; !ok {
+			versions = append(versions, version)
+		}
+		infosByVersion[version] = append(infosByVersion[version], &resource.Info{
+			Object:    obj,
+			Namespace: objMeta.GetNamespace(),
+			Name:      objMeta.GetName(),
+		})
+	}
+
+	results := admission.AdmissionResultsMap{}
+	for _, version := range versions {
+		adm, err := c.admissionFor(version)
+		if err != nil {
+			return nil, err
+		}
+		versionResults, err := adm.ValidateResources(ctx, true, c.defaultNamespace, infosByVersion[version]...)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range versionResults {
+			results[k] = v
+		}
+	}
+	return results, nil
+}
+
+// namespaceOf returns the namespace the object is evaluated in, the objects
+// without a namespace are evaluated in the default one
+func (c *Checker) namespaceOf(ns string) string {
+	if len(ns) == 0 && c.defaultNamespace != nil {
+		return *c.defaultNamespace
+	}
+	return ns
+}
+
+// admissionFor returns the admission that evaluates the enforce levels
+// against the version, the warn and audit versions stay the same
+func (c *Checker) admissionFor(version psapi.Version) (*admission.ParallelAdmission, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if adm, ok := c.adms[version]; ok {
+		return adm, nil
+	}
+	opts := c.opts
+	opts.PolicyVersions.Enforce = version
+	adm, err := admission.NewParallelAdmission(c.kubeClient, &opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up admission for policy version %s: %w", version, err)
+	}
+	c.adms[version] = adm
+	return adm, nil
+}
+
+func (c *Checker) namespacePolicyVersion(ctx context.Context, ns string) (psapi.Version, error) {
+	defaultVersion := c.opts.PolicyVersions.Enforce
+	liveNS, err := c.nsGetter.GetNamespace(ctx, ns)
+	if apierrors.IsNotFound(err) {
+		return defaultVersion, nil
+	} else if err != nil {
+		return defaultVersion, err
+	}
+
+	label, ok := liveNS.Labels[c.versionLabel]
+	if !ok {
+		return defaultVersion, nil
+	}
+	version, err := psapi.ParseVersion(label)
+	if err != nil {
+		klog.V(2).InfoS("Ignoring the invalid policy version of namespace", "namespace", ns, "label", c.versionLabel, "value", label, "err", err)
+		return defaultVersion, nil
+	}
+	return version, nil
+}
@@ -0,0 +1,155 @@
+package checker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	psadmission "k8s.io/pod-security-admission/admission"
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// readExample decodes the single document of the manifest in the examples
+// directory, with its apiVersion and kind set
+func readExample(t *testing.T, name string) runtime.Object {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "examples", name))
+	if err != nil {
+		t.Fatalf("failed to read the example: %v", err)
+	}
+	obj, gvk, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to decode the example: %v", err)
+	}
+	obj.GetObjectKind().SetGroupVersionKind(*gvk)
+	return obj
+}
+
+func testOptions() *Options {
+	latest := psapi.LatestVersion()
+	return &Options{
+		ParallelAdmissionOptions: admission.ParallelAdmissionOptions{
+			PolicyVersions: admission.PolicyVersions{Enforce: latest, Warn: latest, Audit: latest},
+			MaxConcurrency: 1,
+		},
+	}
+}
+
+func TestCheckPod(t *testing.T) {
+	pod := readExample(t, "pod-baseline.yaml")
+
+	results, err := Check(context.Background(), fake.NewSimpleClientset(), []runtime.Object{pod}, testOptions())
+	if err != nil {
+		t.Fatalf("failed to check the pod: %v", err)
+	}
+
+	key := admission.AdmissionResultsKey{GVK: corev1.SchemeGroupVersion.WithKind("Pod"), Namespace: "tools", Name: "toolbox"}
+	result, ok := results[key]
+	if !ok {
+		t.Fatalf("missing the result of the pod, got %v", results)
+	}
+	if level := result.Level(); level != Baseline {
+		t.Errorf("expected the pod to require %s, got %s", Baseline, level)
+	}
+	// the admission denies the pods themselves rather than warning about
+	// their templates the way it does for the pod controllers
+	if result.Restricted.Allowed {
+		t.Errorf("expected the pod to be denied at the restricted level, got warnings %v", result.Restricted.Warnings)
+	}
+	if !result.Baseline.Allowed || len(result.Baseline.Warnings) > 0 {
+		t.Errorf("expected the pod to be admitted at the baseline level, got %s", result)
+	}
+}
+
+func TestCheckCronJob(t *testing.T) {
+	cronJob := readExample(t, "cronjob-privilege-escalation.yaml")
+
+	results, err := Check(context.Background(), fake.NewSimpleClientset(), []runtime.Object{cronJob}, testOptions())
+	if err != nil {
+		t.Fatalf("failed to check the cronjob: %v", err)
+	}
+
+	key := admission.AdmissionResultsKey{GVK: batchv1.SchemeGroupVersion.WithKind("CronJob"), Namespace: "pipelines", Name: "cleanup"}
+	result, ok := results[key]
+	if !ok {
+		t.Fatalf("missing the result of the cronjob, got %v", results)
+	}
+	if level := result.Level(); level != Baseline {
+		t.Errorf("expected the cronjob to require %s, got %s", Baseline, level)
+	}
+	if len(result.FailedChecks) != 1 || result.FailedChecks[0].ID != "allowPrivilegeEscalation" {
+		t.Fatalf("expected the cronjob to only fail the allowPrivilegeEscalation check, got %v", result.FailedChecks)
+	}
+	if containers := result.FailedChecks[0].Containers; len(containers) != 1 || containers[0] != "cleanup" {
+		t.Errorf("expected the check to fail on the cleanup container, got %v", containers)
+	}
+
+	nsResult := admission.AggregateResultsPerNamespace(results)["pipelines"]
+	if nsResult == nil || len(nsResult.Objects) != 1 || nsResult.Objects[0].Kind != "CronJob" {
+		t.Errorf("expected the namespace result to list the cronjob, got %v", nsResult)
+	}
+}
+
+func TestCheckPinnedVersions(t *testing.T) {
+	pinned := psapi.MajorMinorVersion(1, 22)
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tools", Labels: map[string]string{psapi.EnforceVersionLabel: pinned.String()}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps", Labels: map[string]string{psapi.EnforceVersionLabel: "invalid"}}},
+	)
+	newPod := func(namespace string) runtime.Object {
+		pod := readExample(t, "pod-baseline.yaml")
+		pod.(*corev1.Pod).Namespace = namespace
+		return pod
+	}
+	objects := []runtime.Object{newPod("tools"), newPod("apps"), newPod("missing")}
+
+	latest := psapi.LatestVersion()
+	tests := []struct {
+		name     string
+		nsGetter psadmission.NamespaceGetter
+		expected map[string]psapi.Version
+	}{
+		{
+			name:     "unlabeled namespaces",
+			expected: map[string]psapi.Version{"tools": latest, "apps": latest, "missing": latest},
+		},
+		{
+			name:     "pinned versions",
+			nsGetter: psadmission.NamespaceGetterFromClient(client),
+			// the invalid pins and the missing namespaces fall back to the version of the options
+			expected: map[string]psapi.Version{"tools": pinned, "apps": latest, "missing": latest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testOptions()
+			opts.NamespaceGetter = tt.nsGetter
+
+			results, err := Check(context.Background(), client, objects, opts)
+			if err != nil {
+				t.Fatalf("failed to check the pods: %v", err)
+			}
+			if len(results) != len(tt.expected) {
+				t.Fatalf("expected %d results, got %d", len(tt.expected), len(results))
+			}
+			for key, result := range results {
+				if version := result.PolicyVersion; version != tt.expected[key.Namespace] {
+					t.Errorf("expected the pod in %s to be evaluated against %s, got %s", key.Namespace, tt.expected[key.Namespace], version)
+				}
+				if level := result.Level(); level != Baseline {
+					t.Errorf("expected the pod in %s to require %s, got %s", key.Namespace, Baseline, level)
+				}
+			}
+		})
+	}
+}
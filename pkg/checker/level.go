@@ -0,0 +1,19 @@
+package checker
+
+import "github.com/stlaz/psachecker/pkg/admission"
+
+// Level is a PodSecurity level, the levels are ordered from the most privileged
+// to the most restrictive so that they can be compared, e.g. `level >= Baseline`
+type Level = admission.Level
+
+const (
+	Unknown    = admission.LevelUnknownValue
+	Privileged = admission.LevelPrivilegedValue
+	Baseline   = admission.LevelBaselineValue
+	Restricted = admission.LevelRestrictedValue
+)
+
+// ParseLevel parses the name of a PodSecurity level, e.g. "baseline"
+func ParseLevel(level string) (Level, error) {
+	return admission.ParseLevel(level)
+}
@@ -6,6 +6,14 @@ import (
 
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/config"
+	"github.com/stlaz/psachecker/pkg/kubecontexts"
+	"github.com/stlaz/psachecker/pkg/nslabels"
+	"github.com/stlaz/psachecker/pkg/printers"
 )
 
 func NewClusterInspectCommand(clientConfigOptions *genericclioptions.ConfigFlags) *cobra.Command {
@@ -16,19 +24,66 @@ func NewClusterInspectCommand(clientConfigOptions *genericclioptions.ConfigFlags
 		Short:        "get the least privileged PodSecurity level for your workload/namespace to keep current workloads running successfully",
 		SilenceUsage: true,
 		RunE: func(c *cobra.Command, args []string) error {
-			o.Complete(c, clientConfigOptions)
+			if err := config.LoadFlagDefaults(c); err != nil {
+				return err
+			}
+
+			contexts := cmdutil.GetFlagStringArray(c, "context")
+			if len(contexts) > 1 && cmdutil.GetFlagBool(c, "generate-labels") {
+				return fmt.Errorf("--generate-labels cannot be used with multiple contexts")
+			}
 
-			nsAggregatedResults, err := o.Run(context.Background())
+			requestTimeout, err := clientcmd.ParseTimeout(*clientConfigOptions.Timeout)
 			if err != nil {
 				return err
 			}
+			ctx := context.Background()
+			if requestTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, requestTimeout)
+				defer cancel()
+			}
+
+			var contextResults []*kubecontexts.Result
+			for _, contextFlags := range kubecontexts.ConfigFlags(clientConfigOptions, contexts) {
+				if err := o.Complete(c, contextFlags); err != nil {
+					return err
+				}
+				nsAggregatedResults, err := o.Run(ctx)
+				if err != nil {
+					return err
+				}
+
+				contextResult := &kubecontexts.Result{KubeClient: o.kubeClient, Results: nsAggregatedResults}
+				if contextFlags.Context != nil {
+					contextResult.Context = *contextFlags.Context
+				}
+				contextResults = append(contextResults, contextResult)
+			}
+			nsAggregatedResults := kubecontexts.MergeResults(contextResults)
+
+			if err := printers.WriteResults(c.OutOrStdout(), nsAggregatedResults, o.printOptions); err != nil {
+				return err
+			}
+			if o.printOptions.ExitSummary {
+				printers.PrintExitSummary(c.ErrOrStderr(), nsAggregatedResults, o.maxLevel)
+			}
+
+			if o.applyOptions != nil {
+				for _, contextResult := range contextResults {
+					if err := nslabels.ApplyLabels(ctx, c.OutOrStdout(), contextResult.KubeClient, contextResult.Results, o.applyOptions); err != nil {
+						return err
+					}
+				}
+			}
 
-			for _, ns := range nsAggregatedResults.Keys() {
-				fmt.Fprintf(c.OutOrStdout(), "%s: %s\n", ns, nsAggregatedResults.Get(ns))
+			if len(o.maxLevel) > 0 {
+				return admission.CheckMaxLevel(nsAggregatedResults, o.maxLevel)
 			}
 			return nil
 		},
 	}
 
+	o.AddFlags(cmd)
 	return cmd
 }
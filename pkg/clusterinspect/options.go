@@ -3,35 +3,155 @@ package clusterinspect
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/kubernetes"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	psadmissionapi "k8s.io/pod-security-admission/admission/api"
 	psapi "k8s.io/pod-security-admission/api"
 
 	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/apiretry"
+	"github.com/stlaz/psachecker/pkg/config"
+	"github.com/stlaz/psachecker/pkg/nslabels"
+	"github.com/stlaz/psachecker/pkg/openshift"
+	"github.com/stlaz/psachecker/pkg/printers"
+	"github.com/stlaz/psachecker/pkg/progress"
 )
 
 type ClusterInspectOptions struct {
 	clientConfigOptions *genericclioptions.ConfigFlags
 
-	updatesOnly bool
+	updatesOnly      bool
+	compareLabels    bool
+	showSCCs         bool
+	maxLevel         psapi.Level
+	maxRetries       int
+	admissionOptions *admission.ParallelAdmissionOptions
+	applyOptions     *nslabels.ApplyOptions
+	printOptions     *printers.PrintOptions
+
+	// psaDefaults are the levels and versions of the namespaces without
+	// PodSecurity labels in the cluster, only set with --psa-config
+	psaDefaults *psadmissionapi.PodSecurityDefaults
 
 	kubeClient kubernetes.Interface
 }
 
 func newClusterInspectOptions() *ClusterInspectOptions {
-	return &ClusterInspectOptions{}
+	return &ClusterInspectOptions{
+		admissionOptions: &admission.ParallelAdmissionOptions{},
+		printOptions:     &printers.PrintOptions{},
+	}
+}
+
+func (o *ClusterInspectOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.showSCCs, "show-sccs", false, "Show the OpenShift SecurityContextConstraints that the pods of each namespace were admitted by next to the computed level.")
 }
 
 func (o *ClusterInspectOptions) Complete(cmd *cobra.Command, clientConfigOptions *genericclioptions.ConfigFlags) error {
 	o.updatesOnly = cmdutil.GetFlagBool(cmd, "updates-only")
+	o.compareLabels = cmdutil.GetFlagBool(cmd, "compare-labels")
+	if err := o.printOptions.Complete(cmd); err != nil {
+		return err
+	}
+	if maxLevel := cmdutil.GetFlagString(cmd, "max-level"); len(maxLevel) > 0 {
+		level, err := psapi.ParseLevel(maxLevel)
+		if err != nil {
+			return fmt.Errorf("invalid --max-level value: %w", err)
+		}
+		o.maxLevel = level
+		o.printOptions.MaxLevel = level
+	}
+	if targetLevel := cmdutil.GetFlagString(cmd, "target-level"); len(targetLevel) > 0 {
+		level, err := psapi.ParseLevel(targetLevel)
+		if err != nil {
+			return fmt.Errorf("invalid --target-level value: %w", err)
+		}
+		o.printOptions.TargetLevel = level
+	}
+	// sets the policy versions of the unlabeled namespaces as well
+	psaConfig, err := config.LoadPodSecurityConfig(cmd)
+	if err != nil {
+		return err
+	}
+	// the discovered resources are cached in the --cache-dir across the runs
+	server, err := clientConfigOptions.ToDiscoveryClient()
+	if err != nil {
+		return err
+	}
+	if cmdutil.GetFlagBool(cmd, "flush-cache") {
+		server.Invalidate()
+	}
+	versionValue := cmdutil.GetFlagString(cmd, "policy-version")
+	if strings.Contains(versionValue, ",") {
+		return fmt.Errorf("several --policy-version values are only supported by inspect-workloads")
+	}
+	policyVersion, fromServer, err := admission.ParsePolicyVersion(versionValue, server, cmdutil.GetFlagInt(cmd, "max-retries"))
+	if err != nil {
+		return fmt.Errorf("failed to resolve --policy-version=%s: %w", versionValue, err)
+	}
+	if fromServer {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Evaluating against the PodSecurity policy version %s of the server\n", policyVersion)
+	}
+	o.admissionOptions.PolicyVersions = admission.PolicyVersions{Enforce: policyVersion, Warn: policyVersion, Audit: policyVersion}
+	for flag, version := range map[string]*psapi.Version{
+		"warn-policy-version":  &o.admissionOptions.PolicyVersions.Warn,
+		"audit-policy-version": &o.admissionOptions.PolicyVersions.Audit,
+	} {
+		if v := cmdutil.GetFlagString(cmd, flag); len(v) > 0 {
+			if *version, err = psapi.ParseVersion(v); err != nil {
+				return fmt.Errorf("invalid --%s value: %w", flag, err)
+			}
+		}
+	}
+	if cmdutil.GetFlagBool(cmd, "use-warn-level") {
+		// the reported level is evaluated the same way as the warn level, the
+		// generated labels pin the warn version so that they match the level
+		policyVersion = o.admissionOptions.PolicyVersions.Warn
+		o.admissionOptions.PolicyVersions.Enforce = policyVersion
+	}
+	o.printOptions.PolicyVersions = o.admissionOptions.PolicyVersions
+
+	if cmdutil.GetFlagBool(cmd, "apply") {
+		dryRun, err := cmdutil.GetDryRunStrategy(cmd)
+		if err != nil {
+			return err
+		}
+		o.applyOptions = &nslabels.ApplyOptions{
+			DryRun:        dryRun,
+			AllowRelax:    cmdutil.GetFlagBool(cmd, "allow-relax"),
+			PolicyVersion: policyVersion,
+		}
+	}
 	o.clientConfigOptions = clientConfigOptions
 
+	o.admissionOptions.MaxConcurrency = cmdutil.GetFlagInt(cmd, "max-concurrency")
+	if o.maxRetries = cmdutil.GetFlagInt(cmd, "max-retries"); o.maxRetries < 0 {
+		return fmt.Errorf("--max-retries must not be negative")
+	}
+	o.admissionOptions.Exemptions = psadmissionapi.PodSecurityExemptions{
+		Namespaces:     cmdutil.GetFlagStringSlice(cmd, "exempt-namespace"),
+		RuntimeClasses: cmdutil.GetFlagStringSlice(cmd, "exempt-runtime-class"),
+		Usernames:      cmdutil.GetFlagStringSlice(cmd, "exempt-user"),
+	}
+	if psaConfig != nil {
+		o.admissionOptions.Exemptions = admission.MergeExemptions(o.admissionOptions.Exemptions, psaConfig.Exemptions)
+		o.psaDefaults = &psaConfig.Defaults
+	}
+	o.admissionOptions.IgnoredChecks = cmdutil.GetFlagStringSlice(cmd, "ignore-control")
+	if progress.Enabled(cmd) {
+		o.admissionOptions.Progress = progress.NewReporter(cmd.ErrOrStderr()).Report
+	}
+	// the exempt users are matched against the user the objects would be created by
+	o.admissionOptions.Username = *o.clientConfigOptions.Impersonate
+
 	clientConfig, err := o.clientConfigOptions.ToRawKubeConfigLoader().ClientConfig()
 	if err != nil {
 		return fmt.Errorf("failed to read kube client configuration")
@@ -45,8 +165,8 @@ func (o *ClusterInspectOptions) Complete(cmd *cobra.Command, clientConfigOptions
 	return nil
 }
 
-func (o *ClusterInspectOptions) Run(ctx context.Context) (*admission.OrderedStringToPSALevelMap, error) {
-	adm, err := admission.NewParallelAdmission(o.kubeClient)
+func (o *ClusterInspectOptions) Run(ctx context.Context) (*admission.OrderedNamespaceResultsMap, error) {
+	adm, err := admission.NewParallelAdmission(o.kubeClient, o.admissionOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to set up admission: %w", err)
 	}
@@ -55,7 +175,12 @@ func (o *ClusterInspectOptions) Run(ctx context.Context) (*admission.OrderedStri
 	if o.clientConfigOptions.Namespace != nil && *o.clientConfigOptions.Namespace != "" {
 		listOpts.FieldSelector = fields.OneTermEqualSelector("metadata.name", *o.clientConfigOptions.Namespace).String()
 	}
-	namespacesList, err := o.kubeClient.CoreV1().Namespaces().List(ctx, listOpts)
+	var namespacesList *corev1.NamespaceList
+	err = apiretry.OnError(o.maxRetries, func() error {
+		var err error
+		namespacesList, err = o.kubeClient.CoreV1().Namespaces().List(ctx, listOpts)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list namespaces: %w", err)
 	}
@@ -64,16 +189,27 @@ func (o *ClusterInspectOptions) Run(ctx context.Context) (*admission.OrderedStri
 	if err != nil {
 		return nil, err
 	}
-	if o.updatesOnly {
+	if o.updatesOnly || o.compareLabels {
 		for _, origNS := range namespacesList.Items {
-			suggestedLevel := nsAggregatedResults[origNS.Name]
-			// FIXME: we need to take the global config into account during the validation otherwise
-			//        this is going to include NSes that don't need updating
-			if string(suggestedLevel) == origNS.Labels[psapi.EnforceLevelLabel] {
+			nsResult := nsAggregatedResults[origNS.Name]
+			nsLabels := admission.WithDefaultLabels(origNS.Labels, o.psaDefaults)
+			if o.compareLabels {
+				nsResult.CompareLabels(nsLabels)
+			}
+			if o.updatesOnly && nsResult.Level.String() == nsLabels[psapi.EnforceLevelLabel] {
 				delete(nsAggregatedResults, origNS.Name)
 			}
 		}
 	}
 
-	return admission.NewOrderedStringToPSALevelMap(nsAggregatedResults), nil
+	if o.showSCCs {
+		for ns, nsResult := range nsAggregatedResults {
+			if nsResult.SCCs, err = openshift.NamespaceSCCs(ctx, o.kubeClient, ns); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return admission.NewOrderedNamespaceResultsMap(nsAggregatedResults), nil
+
 }
@@ -0,0 +1,96 @@
+// Package config loads the defaults of the command line flags from a config file
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// FileName is looked up in the current working directory first and in the
+// home directory of the user then, only the first file found is loaded
+const FileName = ".psachecker.yaml"
+
+// LoadFlagDefaults sets the flags of the command that were not set on the
+// command line to the values of the config file. The keys of the file are the
+// names of the flags, list values set flags that can be repeated once per item.
+func LoadFlagDefaults(cmd *cobra.Command) error {
+	path, err := findConfigFile()
+	if err != nil || len(path) == 0 {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the config file: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("failed to parse the config file %q: %w", path, err)
+	}
+	klog.V(2).InfoS("Loading the flag defaults", "path", path)
+
+	// set the flags in a stable order so that the errors are reproducible
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	flags := cmd.Flags()
+	for _, name := range keys {
+		flag := flags.Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("unknown flag %q in the config file %q", name, path)
+		}
+		// the command line takes precedence, this also makes repeated loading a no-op
+		if flag.Changed {
+			continue
+		}
+		if err := setFlag(flags, flag, values[name]); err != nil {
+			return fmt.Errorf("invalid value of %q in the config file %q: %w", name, path, err)
+		}
+	}
+	return nil
+}
+
+func setFlag(flags *pflag.FlagSet, flag *pflag.Flag, value interface{}) error {
+	items, ok := value.([]interface{})
+	if !ok {
+		items = []interface{}{value}
+	}
+	for _, item := range items {
+		if _, isList := item.([]interface{}); isList {
+			return fmt.Errorf("nested lists are not supported")
+		}
+		if err := flags.Set(flag.Name, fmt.Sprint(item)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findConfigFile() (string, error) {
+	dirs := []string{"."}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, home)
+	}
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to check for the config file: %w", err)
+		}
+	}
+	return "", nil
+}
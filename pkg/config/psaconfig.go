@@ -0,0 +1,104 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	psadmissionapi "k8s.io/pod-security-admission/admission/api"
+	"k8s.io/pod-security-admission/admission/api/load"
+	"k8s.io/pod-security-admission/admission/api/validation"
+	psapi "k8s.io/pod-security-admission/api"
+	"sigs.k8s.io/yaml"
+)
+
+// podSecurityPluginName is the name of the PodSecurity admission plugin in
+// the AdmissionConfiguration of the API server
+const podSecurityPluginName = "PodSecurity"
+
+// admissionConfiguration is the part of the AdmissionConfiguration of the
+// API server that configures the admission plugins, the types are not
+// vendored as they come with the whole API server
+type admissionConfiguration struct {
+	Kind    string `json:"kind"`
+	Plugins []struct {
+		Name          string          `json:"name"`
+		Path          string          `json:"path"`
+		Configuration json.RawMessage `json:"configuration"`
+	} `json:"plugins"`
+}
+
+// LoadPodSecurityConfig loads the PodSecurity admission configuration of the
+// --psa-config file, nil if it is not set. The policy version flags that were
+// not set are set to the default versions of the configuration so that the
+// unlabeled namespaces are evaluated the same way as in the cluster.
+func LoadPodSecurityConfig(cmd *cobra.Command) (*psadmissionapi.PodSecurityConfiguration, error) {
+	path := cmdutil.GetFlagString(cmd, "psa-config")
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	psaConfig, err := loadPodSecurityConfigFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the PodSecurity configuration %q: %w", path, err)
+	}
+	if errs := validation.ValidatePodSecurityConfiguration(psaConfig); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid PodSecurity configuration %q: %w", path, errs.ToAggregate())
+	}
+
+	flags := cmd.Flags()
+	for name, version := range map[string]string{
+		"policy-version":       psaConfig.Defaults.EnforceVersion,
+		"warn-policy-version":  psaConfig.Defaults.WarnVersion,
+		"audit-policy-version": psaConfig.Defaults.AuditVersion,
+	} {
+		// latest stands for the version of the cluster, the same as the flags default to
+		if flag := flags.Lookup(name); flag == nil || flag.Changed || version == psapi.VersionLatest {
+			continue
+		}
+		if err := flags.Set(name, version); err != nil {
+			return nil, fmt.Errorf("invalid default version %q in the PodSecurity configuration %q: %w", version, path, err)
+		}
+	}
+	return psaConfig, nil
+}
+
+// loadPodSecurityConfigFile accepts either the AdmissionConfiguration passed
+// to the API server or the PodSecurityConfiguration of the plugin itself
+func loadPodSecurityConfigFile(path string) (*psadmissionapi.PodSecurityConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	admissionConfig := &admissionConfiguration{}
+	if err := yaml.Unmarshal(data, admissionConfig); err != nil {
+		return nil, err
+	}
+	if admissionConfig.Kind != "AdmissionConfiguration" {
+		return load.LoadFromData(data)
+	}
+
+	for _, plugin := range admissionConfig.Plugins {
+		if plugin.Name != podSecurityPluginName {
+			continue
+		}
+		if len(plugin.Configuration) > 0 && string(plugin.Configuration) != "null" {
+			return load.LoadFromData(plugin.Configuration)
+		}
+		if len(plugin.Path) == 0 {
+			// the plugin runs with the default configuration
+			return load.LoadFromData(nil)
+		}
+		// the API server resolves the relative paths against the directory of the file
+		pluginPath := plugin.Path
+		if !filepath.IsAbs(pluginPath) {
+			pluginPath = filepath.Join(filepath.Dir(path), pluginPath)
+		}
+		return load.LoadFromFile(pluginPath)
+	}
+	return nil, fmt.Errorf("the AdmissionConfiguration does not configure the %s plugin", podSecurityPluginName)
+}
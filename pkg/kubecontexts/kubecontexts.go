@@ -0,0 +1,71 @@
+package kubecontexts
+
+import (
+	"fmt"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// Result holds the results of a run against a single kubeconfig context
+type Result struct {
+	// Context is empty if no context was explicitly selected
+	Context    string
+	KubeClient kubernetes.Interface
+	Results    *admission.OrderedNamespaceResultsMap
+}
+
+// ConfigFlags returns a copy of the client config flags for each of the contexts,
+// the flags are returned as they are if no contexts were selected
+func ConfigFlags(flags *genericclioptions.ConfigFlags, contexts []string) []*genericclioptions.ConfigFlags {
+	if len(contexts) == 0 {
+		return []*genericclioptions.ConfigFlags{flags}
+	}
+
+	ret := make([]*genericclioptions.ConfigFlags, 0, len(contexts))
+	for _, context := range contexts {
+		contextFlags := genericclioptions.NewConfigFlags(true)
+		contextFlags.CacheDir = flags.CacheDir
+		contextFlags.KubeConfig = flags.KubeConfig
+		contextFlags.ClusterName = flags.ClusterName
+		contextFlags.AuthInfoName = flags.AuthInfoName
+		contextFlags.Namespace = flags.Namespace
+		contextFlags.APIServer = flags.APIServer
+		contextFlags.TLSServerName = flags.TLSServerName
+		contextFlags.Insecure = flags.Insecure
+		contextFlags.CertFile = flags.CertFile
+		contextFlags.KeyFile = flags.KeyFile
+		contextFlags.CAFile = flags.CAFile
+		contextFlags.BearerToken = flags.BearerToken
+		contextFlags.Impersonate = flags.Impersonate
+		contextFlags.ImpersonateUID = flags.ImpersonateUID
+		contextFlags.ImpersonateGroup = flags.ImpersonateGroup
+		contextFlags.Username = flags.Username
+		contextFlags.Password = flags.Password
+		contextFlags.Timeout = flags.Timeout
+		contextFlags.WrapConfigFn = flags.WrapConfigFn
+
+		context := context
+		contextFlags.Context = &context
+		ret = append(ret, contextFlags)
+	}
+	return ret
+}
+
+// MergeResults returns the results of a single context unchanged, the
+// namespaces of results from multiple contexts are qualified as "context/namespace"
+func MergeResults(results []*Result) *admission.OrderedNamespaceResultsMap {
+	if len(results) == 1 {
+		return results[0].Results
+	}
+
+	merged := admission.NewOrderedNamespaceResultsMap(nil)
+	for _, contextResult := range results {
+		for _, ns := range contextResult.Results.Keys() {
+			merged.Set(fmt.Sprintf("%s/%s", contextResult.Context, ns), contextResult.Results.Get(ns))
+		}
+	}
+	return merged
+}
@@ -0,0 +1,97 @@
+package nslabels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+type ApplyOptions struct {
+	DryRun cmdutil.DryRunStrategy
+	// AllowRelax allows setting an enforce level that is less restrictive than the current one
+	AllowRelax    bool
+	PolicyVersion psapi.Version
+}
+
+// ApplyLabels sets the enforce labels for the computed levels on the live
+// namespaces and reports what happened to each of them
+func ApplyLabels(ctx context.Context, w io.Writer, client kubernetes.Interface, results *admission.OrderedNamespaceResultsMap, opts *ApplyOptions) error {
+	var dryRunSuffix string
+	switch opts.DryRun {
+	case cmdutil.DryRunClient:
+		dryRunSuffix = " (dry run)"
+	case cmdutil.DryRunServer:
+		dryRunSuffix = " (server dry run)"
+	}
+
+	for _, ns := range results.Keys() {
+		nsResult := results.Get(ns)
+		level := nsResult.Level
+		if !level.Valid() {
+			fmt.Fprintf(w, "namespace/%s skipped: failed to compute the level\n", ns)
+			continue
+		}
+
+		liveNS, err := client.CoreV1().Namespaces().Get(ctx, ns, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to retrieve namespace %q: %w", ns, err)
+		}
+
+		// the version pinned by the namespace is kept, the level was evaluated against it
+		version := opts.PolicyVersion.String()
+		if len(nsResult.PolicyVersion) > 0 {
+			version = nsResult.PolicyVersion
+		}
+
+		currentLevel := psapi.Level(liveNS.Labels[psapi.EnforceLevelLabel])
+		if currentLevel == level.PSALevel() && liveNS.Labels[psapi.EnforceVersionLabel] == version {
+			fmt.Fprintf(w, "namespace/%s unchanged\n", ns)
+			continue
+		}
+
+		if currentLevel.Valid() && level < admission.LevelValue(currentLevel) && !opts.AllowRelax {
+			fmt.Fprintf(w, "namespace/%s skipped: would relax the enforce level from %q to %q, use --allow-relax to allow that\n", ns, currentLevel, level)
+			continue
+		}
+
+		if opts.DryRun != cmdutil.DryRunClient {
+			if err := patchLabels(ctx, client, ns, level.PSALevel(), version, opts); err != nil {
+				return fmt.Errorf("failed to label namespace %q: %w", ns, err)
+			}
+		}
+		fmt.Fprintf(w, "namespace/%s labeled%s\n", ns, dryRunSuffix)
+	}
+
+	return nil
+}
+
+func patchLabels(ctx context.Context, client kubernetes.Interface, ns string, level psapi.Level, version string, opts *ApplyOptions) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{
+				psapi.EnforceLevelLabel:   string(level),
+				psapi.EnforceVersionLabel: version,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	patchOpts := metav1.PatchOptions{}
+	if opts.DryRun == cmdutil.DryRunServer {
+		patchOpts.DryRun = []string{metav1.DryRunAll}
+	}
+
+	_, err = client.CoreV1().Namespaces().Patch(ctx, ns, types.MergePatchType, patch, patchOpts)
+	return err
+}
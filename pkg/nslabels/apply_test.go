@@ -0,0 +1,148 @@
+package nslabels
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+var testVersion = psapi.MajorMinorVersion(1, 23)
+
+func newNamespace(name string, level psapi.Level) *corev1.Namespace {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if len(level) > 0 {
+		ns.Labels = map[string]string{
+			psapi.EnforceLevelLabel:   string(level),
+			psapi.EnforceVersionLabel: testVersion.String(),
+		}
+	}
+	return ns
+}
+
+func TestApplyLabels(t *testing.T) {
+	newClient := func() *fake.Clientset {
+		return fake.NewSimpleClientset(
+			newNamespace("matching", psapi.LevelBaseline),
+			newNamespace("relaxed", psapi.LevelRestricted),
+			newNamespace("tightened", psapi.LevelPrivileged),
+			newNamespace("unlabeled", ""),
+		)
+	}
+	results := admission.NewOrderedNamespaceResultsMap(map[string]*admission.NamespaceResult{
+		"matching":  {Level: admission.LevelBaselineValue},
+		"relaxed":   {Level: admission.LevelBaselineValue},
+		"tightened": {Level: admission.LevelRestrictedValue},
+		"unlabeled": {Level: admission.LevelBaselineValue},
+		"unknown":   {Level: admission.LevelUnknownValue},
+	})
+
+	tests := []struct {
+		name            string
+		opts            ApplyOptions
+		expectedOutput  []string
+		expectedPatched []string
+	}{
+		{
+			name: "relaxing not allowed",
+			expectedOutput: []string{
+				"namespace/matching unchanged",
+				`namespace/relaxed skipped: would relax the enforce level from "restricted" to "baseline", use --allow-relax to allow that`,
+				"namespace/tightened labeled",
+				"namespace/unknown skipped: failed to compute the level",
+				"namespace/unlabeled labeled",
+			},
+			expectedPatched: []string{"tightened", "unlabeled"},
+		},
+		{
+			name: "relaxing allowed",
+			opts: ApplyOptions{AllowRelax: true},
+			expectedOutput: []string{
+				"namespace/matching unchanged",
+				"namespace/relaxed labeled",
+				"namespace/tightened labeled",
+				"namespace/unknown skipped: failed to compute the level",
+				"namespace/unlabeled labeled",
+			},
+			expectedPatched: []string{"relaxed", "tightened", "unlabeled"},
+		},
+		{
+			name: "client dry run",
+			opts: ApplyOptions{DryRun: cmdutil.DryRunClient},
+			expectedOutput: []string{
+				"namespace/matching unchanged",
+				`namespace/relaxed skipped: would relax the enforce level from "restricted" to "baseline", use --allow-relax to allow that`,
+				"namespace/tightened labeled (dry run)",
+				"namespace/unknown skipped: failed to compute the level",
+				"namespace/unlabeled labeled (dry run)",
+			},
+		},
+		{
+			name: "server dry run",
+			opts: ApplyOptions{DryRun: cmdutil.DryRunServer},
+			expectedOutput: []string{
+				"namespace/matching unchanged",
+				`namespace/relaxed skipped: would relax the enforce level from "restricted" to "baseline", use --allow-relax to allow that`,
+				"namespace/tightened labeled (server dry run)",
+				"namespace/unknown skipped: failed to compute the level",
+				"namespace/unlabeled labeled (server dry run)",
+			},
+			// the server is the one not to persist the patches
+			expectedPatched: []string{"tightened", "unlabeled"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newClient()
+			opts := tt.opts
+			opts.PolicyVersion = testVersion
+
+			out := &bytes.Buffer{}
+			if err := ApplyLabels(context.Background(), out, client, results, &opts); err != nil {
+				t.Fatalf("failed to apply the labels: %v", err)
+			}
+			if output := strings.Split(strings.TrimSpace(out.String()), "\n"); !reflect.DeepEqual(output, tt.expectedOutput) {
+				t.Errorf("expected the output:\n%s\ngot:\n%s", strings.Join(tt.expectedOutput, "\n"), out.String())
+			}
+
+			var patched []string
+			for _, action := range client.Actions() {
+				if patch, ok := action.(clienttesting.PatchAction); ok && action.GetVerb() == "patch" {
+					patched = append(patched, patch.GetName())
+				}
+			}
+			sort.Strings(patched)
+			if !reflect.DeepEqual(patched, tt.expectedPatched) {
+				t.Errorf("expected the namespaces %v to be patched, got %v", tt.expectedPatched, patched)
+			}
+
+			if tt.opts.DryRun != cmdutil.DryRunNone {
+				return
+			}
+			for _, ns := range tt.expectedPatched {
+				liveNS, err := client.CoreV1().Namespaces().Get(context.Background(), ns, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("failed to get namespace %s: %v", ns, err)
+				}
+				expectedLevel := results.Get(ns).Level.String()
+				if level := liveNS.Labels[psapi.EnforceLevelLabel]; level != expectedLevel {
+					t.Errorf("expected namespace %s to enforce %q, got %q", ns, expectedLevel, level)
+				}
+				if version := liveNS.Labels[psapi.EnforceVersionLabel]; version != testVersion.String() {
+					t.Errorf("expected namespace %s to pin %q, got %q", ns, testVersion, version)
+				}
+			}
+		})
+	}
+}
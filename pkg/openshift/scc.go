@@ -0,0 +1,34 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SCCAnnotation is set on the pods by the OpenShift SecurityContextConstraints
+// admission to the name of the SCC the pod was admitted by
+const SCCAnnotation = "openshift.io/scc"
+
+// NamespaceSCCs returns the sorted names of the SecurityContextConstraints
+// that the pods running in the namespace were admitted by
+func NamespaceSCCs(ctx context.Context, kubeClient kubernetes.Interface, namespace string) ([]string, error) {
+	pods, err := kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in namespace %q: %w", namespace, err)
+	}
+
+	seen := map[string]bool{}
+	sccs := []string{}
+	for _, pod := range pods.Items {
+		if scc, ok := pod.Annotations[SCCAnnotation]; ok && !seen[scc] {
+			seen[scc] = true
+			sccs = append(sccs, scc)
+		}
+	}
+	sort.Strings(sccs)
+	return sccs, nil
+}
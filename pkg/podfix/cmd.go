@@ -0,0 +1,65 @@
+package podfix
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/checker"
+)
+
+func NewFixCommand() *cobra.Command {
+	var filenames []string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:          "fix -f FILENAME",
+		Short:        "walk the objects of local files that fail the PodSecurity checks and apply the standard fixes interactively",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(filenames) == 0 {
+				return fmt.Errorf("at least one file must be passed by -f")
+			}
+
+			// there is no server to match the version of
+			policyVersion, _, err := admission.ParsePolicyVersion(cmdutil.GetFlagString(c, "policy-version"), nil, 0)
+			if err != nil {
+				return fmt.Errorf("invalid --policy-version value: %w", err)
+			}
+			// the namespaces don't matter for the checks, the manifests are
+			// evaluated against the mocked ones
+			podChecker, err := checker.NewChecker(nil, &checker.Options{
+				ParallelAdmissionOptions: admission.ParallelAdmissionOptions{
+					PolicyVersions: admission.PolicyVersions{Enforce: policyVersion, Warn: policyVersion, Audit: policyVersion},
+					MaxConcurrency: 1,
+				},
+				DefaultNamespace: "default",
+			})
+			if err != nil {
+				return err
+			}
+
+			f := &fixer{
+				checker: podChecker,
+				yes:     yes,
+				in:      bufio.NewReader(c.InOrStdin()),
+				out:     c.OutOrStdout(),
+			}
+			for _, filename := range filenames {
+				if err := f.fixFile(context.Background(), filename); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&filenames, "filename", "f", nil, "Manifest file to fix, the fixed documents are written back to it. Can be set multiple times.")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Apply all the standard fixes without asking.")
+	return cmd
+}
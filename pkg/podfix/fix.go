@@ -0,0 +1,262 @@
+package podfix
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/checker"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(appsv1.AddToScheme(scheme))
+	utilruntime.Must(batchv1.AddToScheme(scheme))
+}
+
+// document is a document of a manifest file, only the documents that got
+// fixed are encoded again so that the others keep their formatting and comments
+type document struct {
+	raw     []byte
+	obj     *unstructured.Unstructured
+	changed bool
+}
+
+type fixer struct {
+	checker *checker.Checker
+	// yes applies all the fixes without asking
+	yes bool
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// fixFile walks the objects of the file that fail any of the PodSecurity
+// checks and offers the standard fixes, the file is written back if any
+// of them were applied
+func (f *fixer) fixFile(ctx context.Context, path string) error {
+	docs, err := readDocuments(path)
+	if err != nil {
+		return err
+	}
+
+	var changed bool
+	for _, doc := range docs {
+		if doc.obj == nil {
+			continue
+		}
+		if doc.changed, err = f.fixObject(ctx, path, doc.obj); err != nil {
+			return err
+		}
+		changed = changed || doc.changed
+	}
+
+	if !changed {
+		return nil
+	}
+	if err := writeDocuments(path, docs); err != nil {
+		return err
+	}
+	fmt.Fprintf(f.out, "wrote %s\n", path)
+	return nil
+}
+
+func (f *fixer) fixObject(ctx context.Context, path string, obj *unstructured.Unstructured) (bool, error) {
+	result, err := f.evaluate(ctx, obj)
+	if err != nil || result == nil || len(result.FailedChecks) == 0 {
+		return false, err
+	}
+
+	fmt.Fprintf(f.out, "%s/%s in %s requires %s\n", obj.GetKind(), obj.GetName(), path, result.MostRestrictivePolicy())
+	var changed bool
+	for _, check := range result.FailedChecks {
+		fmt.Fprintf(f.out, "    %s requires %s: %s", check.ID, check.RequiredLevel, check.Reason)
+		if len(check.Containers) > 0 {
+			fmt.Fprintf(f.out, " in containers %s", strings.Join(check.Containers, ", "))
+		}
+		if len(check.Detail) > 0 {
+			fmt.Fprintf(f.out, " (%s)", check.Detail)
+		}
+		fmt.Fprintln(f.out)
+
+		fix := remediations[check.ID]
+		if fix == nil {
+			fmt.Fprintf(f.out, "        no standard fix, the manifest needs to be edited by hand\n")
+			continue
+		}
+		if !f.confirm(fix.description) {
+			continue
+		}
+		if err := applyFix(obj, fix, check.Containers); err != nil {
+			return false, fmt.Errorf("failed to fix %s of %s/%s in %s: %w", check.ID, obj.GetKind(), obj.GetName(), path, err)
+		}
+		changed = true
+	}
+
+	if changed {
+		if result, err = f.evaluate(ctx, obj); err != nil {
+			return false, err
+		}
+		fmt.Fprintf(f.out, "%s/%s now requires %s\n", obj.GetKind(), obj.GetName(), result.MostRestrictivePolicy())
+	}
+	return changed, nil
+}
+
+// evaluate returns nil for the objects of kinds unknown to the scheme and
+// for the objects without a pod spec, e.g. ConfigMaps, there is nothing to fix
+func (f *fixer) evaluate(ctx context.Context, obj *unstructured.Unstructured) (*admission.ParallelAdmissionResult, error) {
+	gvk := obj.GroupVersionKind()
+	if !scheme.Recognizes(gvk) {
+		return nil, nil
+	}
+	if _, found, _ := unstructured.NestedMap(obj.Object, podSpecPath(gvk.Kind)...); !found {
+		return nil, nil
+	}
+	typed, err := scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), typed); err != nil {
+		return nil, fmt.Errorf("failed to decode %s/%s: %w", gvk.Kind, obj.GetName(), err)
+	}
+	typed.GetObjectKind().SetGroupVersionKind(gvk)
+
+	results, err := f.checker.Check(ctx, []runtime.Object{typed})
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		return result, nil
+	}
+	return nil, nil
+}
+
+func (f *fixer) confirm(description string) bool {
+	if f.yes {
+		fmt.Fprintf(f.out, "        %s: applied\n", description)
+		return true
+	}
+
+	fmt.Fprintf(f.out, "        %s? [y/N] ", description)
+	answer, err := f.in.ReadString('\n')
+	if err != nil && len(answer) == 0 {
+		// no more input, e.g. the input was not a terminal
+		fmt.Fprintln(f.out)
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// applyFix merges the patch of the fix onto the pod spec of the object
+func applyFix(obj *unstructured.Unstructured, fix *remediation, containers []string) error {
+	path := podSpecPath(obj.GetKind())
+	podSpec, found, err := unstructured.NestedMap(obj.Object, path...)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("missing %s", strings.Join(path, "."))
+	}
+
+	patched, err := strategicpatch.StrategicMergeMapPatch(podSpec, fix.patch(podSpec, containers), &corev1.PodSpec{})
+	if err != nil {
+		return err
+	}
+	return unstructured.SetNestedMap(obj.Object, patched, path...)
+}
+
+// podSpecPath returns the fields leading to the pod spec of the supported kinds
+func podSpecPath(kind string) []string {
+	switch kind {
+	case "Pod":
+		return []string{"spec"}
+	case "PodTemplate":
+		return []string{"template", "spec"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return []string{"spec", "template", "spec"}
+	}
+}
+
+func readDocuments(path string) ([]*document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*document
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		raw, err := reader.Read()
+		if err == io.EOF {
+			return docs, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", path, err)
+		}
+
+		doc := &document{raw: raw}
+		content := map[string]interface{}{}
+		if err := yaml.Unmarshal(raw, &content); err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", path, err)
+		}
+		if len(content) > 0 {
+			doc.obj = &unstructured.Unstructured{Object: content}
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// writeDocuments writes the documents back to the file, JSON files are kept JSON
+func writeDocuments(path string, docs []*document) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for i, doc := range docs {
+		data := doc.raw
+		if doc.changed {
+			if filepath.Ext(path) == ".json" {
+				data, err = json.MarshalIndent(doc.obj.Object, "", "  ")
+			} else {
+				data, err = yaml.Marshal(doc.obj.Object)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to encode %s/%s: %w", doc.obj.GetKind(), doc.obj.GetName(), err)
+			}
+		}
+
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(data)
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			buf.WriteString("\n")
+		}
+	}
+	return os.WriteFile(path, buf.Bytes(), info.Mode())
+}
@@ -0,0 +1,202 @@
+package podfix
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	psapi "k8s.io/pod-security-admission/api"
+	"sigs.k8s.io/yaml"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/checker"
+)
+
+// podSpec has an init container overriding the pod-level runAsNonRoot and a
+// container without any security context, it fails each of the restricted
+// checks that have a remediation
+const podSpec = `
+      initContainers:
+      - name: setup
+        image: busybox
+        securityContext:
+          runAsNonRoot: false
+      containers:
+      - name: app
+        image: busybox
+`
+
+var testManifests = map[string]string{
+	"Deployment": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: apps
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:` + podSpec,
+	"CronJob": `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+  namespace: apps
+spec:
+  schedule: "0 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never` + strings.ReplaceAll(podSpec, "\n      ", "\n          "),
+}
+
+func newTestFixer(t *testing.T) *fixer {
+	t.Helper()
+
+	latest := psapi.LatestVersion()
+	podChecker, err := checker.NewChecker(nil, &checker.Options{
+		ParallelAdmissionOptions: admission.ParallelAdmissionOptions{
+			PolicyVersions: admission.PolicyVersions{Enforce: latest, Warn: latest, Audit: latest},
+			MaxConcurrency: 1,
+		},
+		DefaultNamespace: "default",
+	})
+	if err != nil {
+		t.Fatalf("failed to set up the checker: %v", err)
+	}
+	return &fixer{checker: podChecker, yes: true, in: bufio.NewReader(strings.NewReader("")), out: io.Discard}
+}
+
+func newTestObject(t *testing.T, kind string) *unstructured.Unstructured {
+	t.Helper()
+
+	content := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(testManifests[kind]), &content); err != nil {
+		t.Fatalf("failed to parse the %s manifest: %v", kind, err)
+	}
+	return &unstructured.Unstructured{Object: content}
+}
+
+func TestApplyFix(t *testing.T) {
+	f := newTestFixer(t)
+	ctx := context.Background()
+
+	for kind := range testManifests {
+		for id, fix := range remediations {
+			t.Run(kind+" "+id, func(t *testing.T) {
+				obj := newTestObject(t, kind)
+				result, err := f.evaluate(ctx, obj)
+				if err != nil {
+					t.Fatalf("failed to evaluate the object: %v", err)
+				}
+				var containers []string
+				var failed bool
+				for _, check := range result.FailedChecks {
+					if check.ID == id {
+						failed, containers = true, check.Containers
+					}
+				}
+				if !failed {
+					t.Fatalf("expected the object to fail %s, got %v", id, result.FailedChecks)
+				}
+
+				if err := applyFix(obj, fix, containers); err != nil {
+					t.Fatalf("failed to apply the fix: %v", err)
+				}
+				if result, err = f.evaluate(ctx, obj); err != nil {
+					t.Fatalf("failed to evaluate the fixed object: %v", err)
+				}
+				for _, check := range result.FailedChecks {
+					if check.ID == id {
+						t.Errorf("expected the fix to pass %s, got %s: %s", id, check.Reason, check.Detail)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestFixObjectRestricted(t *testing.T) {
+	f := newTestFixer(t)
+	ctx := context.Background()
+
+	for kind := range testManifests {
+		t.Run(kind, func(t *testing.T) {
+			obj := newTestObject(t, kind)
+			changed, err := f.fixObject(ctx, "manifest.yaml", obj)
+			if err != nil {
+				t.Fatalf("failed to fix the object: %v", err)
+			}
+			if !changed {
+				t.Fatalf("expected the object to be fixed")
+			}
+
+			result, err := f.evaluate(ctx, obj)
+			if err != nil {
+				t.Fatalf("failed to evaluate the fixed object: %v", err)
+			}
+			if level := result.Level(); level != admission.LevelRestrictedValue {
+				t.Errorf("expected the fixed object to be restricted, got %s failing %v", level, result.FailedChecks)
+			}
+		})
+	}
+}
+
+func TestFixFileKeepsUnchangedDocuments(t *testing.T) {
+	configMap := `# the settings of the web server
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web   # keep in sync with the Deployment
+data:
+  port: "8080"
+`
+	// restricted already, it is not changed either
+	pod := `apiVersion: v1
+kind: Pod
+metadata: {name: debug, namespace: apps}
+spec:
+  securityContext: {runAsNonRoot: true, seccompProfile: {type: RuntimeDefault}}
+  containers:
+  - name: debug
+    image: busybox
+    securityContext: {allowPrivilegeEscalation: false, capabilities: {drop: [ALL]}}
+`
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	manifest := configMap + "---\n" + testManifests["Deployment"] + "---\n" + pod
+	if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatalf("failed to write the manifest: %v", err)
+	}
+
+	if err := newTestFixer(t).fixFile(context.Background(), path); err != nil {
+		t.Fatalf("failed to fix the file: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the fixed file: %v", err)
+	}
+	docs := strings.Split(string(data), "---\n")
+	if len(docs) != 3 {
+		t.Fatalf("expected 3 documents, got %d in:\n%s", len(docs), data)
+	}
+	if docs[0] != configMap {
+		t.Errorf("expected the ConfigMap to be written back as it was, got:\n%s", docs[0])
+	}
+	if docs[1] == testManifests["Deployment"] {
+		t.Errorf("expected the Deployment to be fixed")
+	}
+	if docs[2] != pod {
+		t.Errorf("expected the Pod to be written back as it was, got:\n%s", docs[2])
+	}
+}
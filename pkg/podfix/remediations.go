@@ -0,0 +1,89 @@
+package podfix
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/strings/slices"
+)
+
+// remediation is the standard fix of a PodSecurity check
+type remediation struct {
+	description string
+	// patch returns the strategic merge patch of the pod spec that fixes the
+	// check for the pod and the given containers
+	patch func(podSpec map[string]interface{}, containers []string) map[string]interface{}
+}
+
+// remediations are keyed by the IDs of the checks they fix, the checks of
+// the baseline level usually need a decision on what the workload really
+// needs and are left to be fixed by hand
+var remediations = map[string]*remediation{
+	"allowPrivilegeEscalation": {
+		description: "set securityContext.allowPrivilegeEscalation=false",
+		patch:       containersPatch(map[string]interface{}{"allowPrivilegeEscalation": false}),
+	},
+	"capabilities_restricted": {
+		description: `set securityContext.capabilities.drop=["ALL"]`,
+		patch:       containersPatch(map[string]interface{}{"capabilities": map[string]interface{}{"drop": []interface{}{"ALL"}}}),
+	},
+	"runAsNonRoot": {
+		description: "set securityContext.runAsNonRoot=true",
+		patch:       podAndContainersPatch(map[string]interface{}{"runAsNonRoot": true}),
+	},
+	"seccompProfile_restricted": {
+		description: "set securityContext.seccompProfile.type=RuntimeDefault",
+		patch:       podAndContainersPatch(map[string]interface{}{"seccompProfile": map[string]interface{}{"type": "RuntimeDefault"}}),
+	},
+}
+
+// containersPatch sets the security context fields on the given containers,
+// looking up the list each of them is in
+func containersPatch(securityContext map[string]interface{}) func(map[string]interface{}, []string) map[string]interface{} {
+	return func(podSpec map[string]interface{}, containers []string) map[string]interface{} {
+		patch := map[string]interface{}{}
+		for _, list := range []string{"initContainers", "containers", "ephemeralContainers"} {
+			specContainers, _ := podSpec[list].([]interface{})
+			var patched []interface{}
+			for _, c := range specContainers {
+				name, _ := c.(map[string]interface{})["name"].(string)
+				if !slices.Contains(containers, name) {
+					continue
+				}
+				patched = append(patched, map[string]interface{}{
+					"name":            name,
+					"securityContext": runtime.DeepCopyJSONValue(securityContext),
+				})
+			}
+			if len(patched) > 0 {
+				patch[list] = patched
+			}
+		}
+		return patch
+	}
+}
+
+// podAndContainersPatch sets the security context fields on the pod, the
+// failing containers that override them get them set as well
+func podAndContainersPatch(securityContext map[string]interface{}) func(map[string]interface{}, []string) map[string]interface{} {
+	forContainers := containersPatch(securityContext)
+	return func(podSpec map[string]interface{}, containers []string) map[string]interface{} {
+		var overriding []string
+		for _, list := range []string{"initContainers", "containers", "ephemeralContainers"} {
+			specContainers, _ := podSpec[list].([]interface{})
+			for _, c := range specContainers {
+				container, _ := c.(map[string]interface{})
+				name, _ := container["name"].(string)
+				containerContext, _ := container["securityContext"].(map[string]interface{})
+				for field := range securityContext {
+					if _, ok := containerContext[field]; ok && slices.Contains(containers, name) {
+						overriding = append(overriding, name)
+						break
+					}
+				}
+			}
+		}
+
+		patch := forContainers(podSpec, overriding)
+		patch["securityContext"] = runtime.DeepCopyJSONValue(securityContext)
+		return patch
+	}
+}
@@ -0,0 +1,51 @@
+package printers
+
+import (
+	"io"
+	"os"
+
+	"k8s.io/kubectl/pkg/util/term"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+)
+
+// ColorEnabled returns whether the levels printed to w should be colored,
+// they are unless w is not a terminal, noColor is set or the NO_COLOR
+// environment variable is set to any value, see https://no-color.org
+func ColorEnabled(w io.Writer, noColor bool) bool {
+	if noColor {
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return term.IsTerminal(w)
+}
+
+// colorLevel wraps the level in the color of its severity if color is set,
+// the unknown levels are left as they are
+func colorLevel(level admission.Level, color bool) string {
+	if !color {
+		return level.String()
+	}
+
+	var code string
+	switch level {
+	case admission.LevelRestrictedValue:
+		code = colorGreen
+	case admission.LevelBaselineValue:
+		code = colorYellow
+	case admission.LevelPrivilegedValue:
+		code = colorRed
+	default:
+		return level.String()
+	}
+	return code + level.String() + colorReset
+}
@@ -0,0 +1,44 @@
+package printers
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// printCSV prints a record per evaluated object, the namespaces without any
+// objects get a single record with empty kind and name. The current label is
+// only set if the results were compared to the labels of the live namespaces.
+func printCSV(w io.Writer, results *admission.OrderedNamespaceResultsMap) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Namespace", "Kind", "Name", "RequiredLevel", "CurrentLabel"}); err != nil {
+		return err
+	}
+
+	for _, ns := range results.Keys() {
+		nsResult := results.Get(ns)
+		if len(nsResult.Objects) == 0 {
+			if err := cw.Write([]string{ns, "", "", nsResult.Level.String(), describeCurrentLabel(nsResult)}); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, obj := range nsResult.Objects {
+			record := []string{ns, obj.Kind, obj.Name, obj.Result.MostRestrictivePolicy().String(), describeCurrentLabel(nsResult)}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func describeCurrentLabel(nsResult *admission.NamespaceResult) string {
+	if nsResult.CurrentLevel == nil {
+		return ""
+	}
+	return nsResult.CurrentLevel.String()
+}
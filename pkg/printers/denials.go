@@ -0,0 +1,22 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// PrintDenials lists the objects that would be denied at the target level
+func PrintDenials(w io.Writer, denied []*admission.DeniedObject, target psapi.Level) {
+	if len(denied) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "objects that would be denied at the %q level:\n", target)
+	for _, obj := range denied {
+		fmt.Fprintf(w, "    %s/%s/%s: %s\n", obj.Namespace, obj.Kind, obj.Name, obj.Message)
+	}
+}
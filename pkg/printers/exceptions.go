@@ -0,0 +1,96 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// exception is a namespace that does not meet the standard level, distance
+// is the number of levels it is more privileged by
+type exception struct {
+	namespace string
+	result    *admission.NamespaceResult
+	distance  int
+	changes   int
+}
+
+// printExceptions prints the namespaces that cannot meet the standard level
+// as exceptions to it, the ones furthest from it first, along with the
+// changes their objects need to meet it
+func printExceptions(w io.Writer, results *admission.OrderedNamespaceResultsMap, standard psapi.Level) {
+	if len(standard) == 0 {
+		standard = psapi.LevelRestricted
+	}
+
+	var exceptions []exception
+	for _, ns := range results.Keys() {
+		nsResult := results.Get(ns)
+		if nsResult.Level >= admission.LevelValue(standard) {
+			continue
+		}
+		nsResult.CompareToTargetLevel(standard)
+		e := exception{
+			namespace: ns,
+			result:    nsResult,
+			distance:  int(admission.LevelValue(standard) - nsResult.Level),
+		}
+		for _, obj := range nsResult.Objects {
+			e.changes += len(obj.RequiredChanges)
+		}
+		exceptions = append(exceptions, e)
+	}
+	// the namespaces with the same distance that need more changes first
+	sort.SliceStable(exceptions, func(i, j int) bool {
+		if exceptions[i].distance != exceptions[j].distance {
+			return exceptions[i].distance > exceptions[j].distance
+		}
+		if exceptions[i].changes != exceptions[j].changes {
+			return exceptions[i].changes > exceptions[j].changes
+		}
+		return exceptions[i].namespace < exceptions[j].namespace
+	})
+
+	if len(exceptions) == 0 {
+		fmt.Fprintf(w, "all %d namespaces meet %s\n", len(results.Keys()), standard)
+		return
+	}
+	fmt.Fprintf(w, "%d of %d namespaces are exceptions to %s:\n", len(exceptions), len(results.Keys()), standard)
+	for i, e := range exceptions {
+		fmt.Fprintf(w, "%d. %s: %s, %s\n", i+1, e.namespace, e.result.Level, describeDistance(e.distance, e.result.Level, standard))
+		printRequiredChanges(w, e.result)
+	}
+}
+
+func describeDistance(distance int, level admission.Level, standard psapi.Level) string {
+	if level == admission.LevelUnknownValue {
+		return fmt.Sprintf("could not be evaluated against %s", standard)
+	}
+	levels := "levels"
+	if distance == 1 {
+		levels = "level"
+	}
+	return fmt.Sprintf("%d %s more privileged than %s", distance, levels, standard)
+}
+
+// printRequiredChanges lists the objects that keep the namespace from
+// meeting the standard level along with the checks they fail
+func printRequiredChanges(w io.Writer, nsResult *admission.NamespaceResult) {
+	for _, obj := range nsResult.Objects {
+		if obj.MeetsTargetLevel == nil || *obj.MeetsTargetLevel {
+			continue
+		}
+		fmt.Fprintf(w, "    %s/%s requires %s\n", obj.Kind, obj.Name, obj.Result.MostRestrictivePolicy())
+		for _, check := range obj.RequiredChanges {
+			fmt.Fprintf(w, "        %s: %s", check.ID, check.Reason)
+			if len(check.Detail) > 0 {
+				fmt.Fprintf(w, " (%s)", check.Detail)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
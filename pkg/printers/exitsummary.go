@@ -0,0 +1,41 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// PrintExitSummary prints a single "psachecker: scanned=N violations=N
+// highest=level" line for the scripts to grep. The objects, or the namespaces
+// without any, are counted the same way as the JUnit test cases, the
+// violations are the ones that require a more privileged level than the
+// max level, restricted if it is empty.
+func PrintExitSummary(w io.Writer, results *admission.OrderedNamespaceResultsMap, maxLevel psapi.Level) {
+	if len(maxLevel) == 0 {
+		maxLevel = psapi.LevelRestricted
+	}
+
+	var scanned, violations int
+	highest := admission.LevelRestrictedValue
+	for _, ns := range results.Keys() {
+		nsResult := results.Get(ns)
+		if nsResult.Level < highest {
+			highest = nsResult.Level
+		}
+		if len(nsResult.Objects) == 0 {
+			scanned++
+			if nsResult.Level < admission.LevelValue(maxLevel) {
+				violations++
+			}
+			continue
+		}
+		scanned += len(nsResult.Objects)
+	}
+	violations += len(admission.DeniedObjects(results, maxLevel))
+
+	fmt.Fprintf(w, "psachecker: scanned=%d violations=%d highest=%s\n", scanned, violations, highest)
+}
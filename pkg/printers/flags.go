@@ -0,0 +1,53 @@
+package printers
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// Complete sets the options from the output flags shared by the inspect
+// commands so that their results are printed the same way. The levels and
+// the policy versions are left to the commands, they evaluate them as well.
+func (o *PrintOptions) Complete(cmd *cobra.Command) error {
+	o.Format = cmdutil.GetFlagString(cmd, "output")
+	if err := ValidateFormat(o.Format); err != nil {
+		return err
+	}
+
+	var err error
+	if o.Modes, err = admission.ParseModes(cmdutil.GetFlagStringSlice(cmd, "modes")); err != nil {
+		return fmt.Errorf("invalid --modes value: %w", err)
+	}
+	o.GenerateLabels = cmdutil.GetFlagBool(cmd, "generate-labels")
+	o.Summary = cmdutil.GetFlagBool(cmd, "summary")
+	o.ExitSummary = cmdutil.GetFlagBool(cmd, "stderr-summary")
+	o.Quiet = cmdutil.GetFlagBool(cmd, "quiet")
+	o.OutputFile = cmdutil.GetFlagString(cmd, "output-file")
+	if len(o.OutputFile) > 0 && o.Quiet {
+		return fmt.Errorf("--output-file and --quiet are mutually exclusive")
+	}
+	o.NoHeaders = cmdutil.GetFlagBool(cmd, "no-headers")
+	if text := cmdutil.GetFlagString(cmd, "template"); len(text) > 0 {
+		if len(o.Format) > 0 {
+			return fmt.Errorf("--template cannot be used with -o")
+		}
+		if o.Template, err = ParseTemplate(text); err != nil {
+			return err
+		}
+	}
+	o.IncludeRaw = cmdutil.GetFlagBool(cmd, "include-raw")
+	if err := ValidateIncludeRaw(o.IncludeRaw, o.Format); err != nil {
+		return err
+	}
+	// the files are never colored, only the terminals are
+	o.Color = len(o.OutputFile) == 0 && ColorEnabled(cmd.OutOrStdout(), cmdutil.GetFlagBool(cmd, "no-color"))
+	o.SortBy = cmdutil.GetFlagString(cmd, "sort")
+	o.Reverse = cmdutil.GetFlagBool(cmd, "reverse")
+	o.GroupBy = cmdutil.GetFlagString(cmd, "group-by")
+	o.HideCompliant = !cmdutil.GetFlagBool(cmd, "show-compliant")
+	return nil
+}
@@ -0,0 +1,101 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+const (
+	GroupByNamespace = "namespace"
+	// GroupByKind groups the objects of all the namespaces by their kind
+	GroupByKind = "kind"
+)
+
+var supportedGroupBys = []string{GroupByNamespace, GroupByKind}
+
+func ValidateGroupBy(groupBy, format string) error {
+	switch groupBy {
+	case GroupByNamespace, "":
+		return nil
+	case GroupByKind:
+		if format != FormatHuman && format != FormatTable {
+			return fmt.Errorf("--group-by=%s is only supported by the human-readable and the %s output", GroupByKind, FormatTable)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported grouping %q, allowed groupings are: %s", groupBy, strings.Join(supportedGroupBys, ", "))
+	}
+}
+
+// kindGroup are the evaluated objects of a kind, level is the most
+// privileged level any of them requires
+type kindGroup struct {
+	kind    string
+	level   admission.Level
+	objects []kindObject
+}
+
+// kindObject keeps the key of the namespace of the object, it is qualified
+// by the context when inspecting multiple contexts
+type kindObject struct {
+	*admission.ObjectResult
+	namespace string
+}
+
+// groupByKind returns the objects grouped by their kind, the groups are
+// ordered by the kind, the most privileged objects of each go first
+func groupByKind(results *admission.OrderedNamespaceResultsMap) []*kindGroup {
+	groups := map[string]*kindGroup{}
+	for _, ns := range results.Keys() {
+		for _, obj := range results.Get(ns).Objects {
+			group, ok := groups[obj.Kind]
+			if !ok {
+				group = &kindGroup{kind: obj.Kind, level: admission.LevelRestrictedValue}
+				groups[obj.Kind] = group
+			}
+			if level := obj.Result.Level(); level < group.level {
+				group.level = level
+			}
+			group.objects = append(group.objects, kindObject{ObjectResult: obj, namespace: ns})
+		}
+	}
+
+	ret := make([]*kindGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.SliceStable(group.objects, func(i, j int) bool {
+			return group.objects[i].Result.Level() < group.objects[j].Result.Level()
+		})
+		ret = append(ret, group)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].kind < ret[j].kind })
+	return ret
+}
+
+// printByKind prints the most privileged level of each kind followed by
+// the levels of its objects
+func printByKind(w io.Writer, results *admission.OrderedNamespaceResultsMap, color bool) {
+	for _, group := range groupByKind(results) {
+		fmt.Fprintf(w, "%s: %s\n", group.kind, colorLevel(group.level, color))
+		for _, obj := range group.objects {
+			fmt.Fprintf(w, "    %s/%s: %s\n", obj.namespace, obj.Name, colorLevel(obj.Result.Level(), color))
+		}
+	}
+}
+
+func printTableByKind(w io.Writer, results *admission.OrderedNamespaceResultsMap, noHeaders, color bool) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	if !noHeaders {
+		fmt.Fprintln(tw, "KIND\tNAMESPACE\tNAME\tREQUIRED LEVEL")
+	}
+	for _, group := range groupByKind(results) {
+		for _, obj := range group.objects {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", group.kind, obj.namespace, obj.Name, colorLevel(obj.Result.Level(), color))
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,110 @@
+package printers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name          `xml:"testsuites"`
+	Suites  []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Details string `xml:",chardata"`
+}
+
+// printJUnit prints a test case per evaluated object, or per namespace if it
+// has no objects, that fails if the object would be denied at the max level
+func printJUnit(w io.Writer, results *admission.OrderedNamespaceResultsMap, maxLevel psapi.Level) error {
+	if len(maxLevel) == 0 {
+		maxLevel = psapi.LevelRestricted
+	}
+
+	// the objects fail the same way they do with --strict
+	denied := map[*admission.ObjectResult]*admission.DeniedObject{}
+	for _, obj := range admission.DeniedObjects(results, maxLevel) {
+		denied[obj.ObjectResult] = obj
+	}
+
+	suite := &junitTestSuite{Name: fmt.Sprintf("psachecker (max level %s)", maxLevel)}
+	for _, ns := range results.Keys() {
+		nsResult := results.Get(ns)
+		if len(nsResult.Objects) == 0 {
+			testCase := &junitTestCase{ClassName: ns, Name: ns}
+			if nsResult.Level < admission.LevelValue(maxLevel) {
+				testCase.Failure = &junitFailure{
+					Message: fmt.Sprintf("requires %s", nsResult.Level),
+					Type:    nsResult.Level.String(),
+				}
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+			continue
+		}
+
+		for _, obj := range nsResult.Objects {
+			testCase := &junitTestCase{ClassName: ns, Name: fmt.Sprintf("%s/%s", obj.Kind, obj.Name)}
+			if deniedObj, ok := denied[obj]; ok {
+				testCase.Failure = newJUnitFailure(deniedObj, maxLevel)
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+	}
+
+	suite.Tests = len(suite.TestCases)
+	for _, testCase := range suite.TestCases {
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	data, err := xml.MarshalIndent(&junitTestSuites{Suites: []*junitTestSuite{suite}}, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the results to JUnit XML: %w", err)
+	}
+	fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+	return nil
+}
+
+func newJUnitFailure(obj *admission.DeniedObject, maxLevel psapi.Level) *junitFailure {
+	level := obj.Result.MostRestrictivePolicy()
+
+	// only the checks that keep the object from being allowed at the max level
+	var controls []string
+	for _, check := range obj.Result.FailedChecks {
+		if check.RequiredLevel < admission.LevelValue(maxLevel) {
+			controls = append(controls, check.ID)
+		}
+	}
+
+	message := fmt.Sprintf("requires %s", level)
+	if len(controls) > 0 {
+		message = fmt.Sprintf("%s, violates %s", message, strings.Join(controls, ", "))
+	}
+	return &junitFailure{
+		Message: message,
+		Type:    level.String(),
+		Details: obj.Message,
+	}
+}
@@ -0,0 +1,100 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// namespaceLabels is a minimal Namespace manifest that only carries the
+// PodSecurity labels so that it can be applied on top of the existing namespace
+type namespaceLabels struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+}
+
+// modeLabels are the level and version labels of the PodSecurity modes
+var modeLabels = map[admission.Mode][2]string{
+	admission.ModeEnforce: {psapi.EnforceLevelLabel, psapi.EnforceVersionLabel},
+	admission.ModeWarn:    {psapi.WarnLevelLabel, psapi.WarnVersionLabel},
+	admission.ModeAudit:   {psapi.AuditLevelLabel, psapi.AuditVersionLabel},
+}
+
+func printNamespaceLabels(w io.Writer, results *admission.OrderedNamespaceResultsMap, policyVersions admission.PolicyVersions, modes []admission.Mode) error {
+	versions := map[admission.Mode]psapi.Version{
+		admission.ModeEnforce: policyVersions.Enforce,
+		admission.ModeWarn:    policyVersions.Warn,
+		admission.ModeAudit:   policyVersions.Audit,
+	}
+
+	for _, ns := range results.Keys() {
+		nsResult := results.Get(ns)
+		if !nsResult.Level.Valid() {
+			// we failed to evaluate some of the objects, don't suggest anything
+			continue
+		}
+
+		manifest := &namespaceLabels{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+		}
+		manifest.Metadata.Name = ns
+		// the enforce labels are always set, the version pins the policy so that
+		// the level does not change with the cluster version on upgrades
+		manifest.Metadata.Labels = map[string]string{}
+		for _, mode := range append([]admission.Mode{admission.ModeEnforce}, modes...) {
+			level := nsResult.LevelForMode(mode)
+			if !level.Valid() {
+				continue
+			}
+			manifest.Metadata.Labels[modeLabels[mode][0]] = level.String()
+			version := versions[mode].String()
+			if mode == admission.ModeEnforce && len(nsResult.PolicyVersion) > 0 {
+				// keep the version the level was evaluated against
+				version = nsResult.PolicyVersion
+			}
+			manifest.Metadata.Labels[modeLabels[mode][1]] = version
+		}
+
+		data, err := yaml.Marshal(manifest)
+		if err != nil {
+			return fmt.Errorf("failed to marshal labels of namespace %q: %w", ns, err)
+		}
+		fmt.Fprintf(w, "---\n%s", data)
+	}
+	return nil
+}
+
+// printUnlabeledNamespaces lists the namespaces that --compare-labels found
+// without an enforce label along with the levels they could adopt. They
+// enforce nothing stricter than privileged, the ones that could adopt the
+// most restrictive levels are the largest gaps and come first.
+func printUnlabeledNamespaces(w io.Writer, results *admission.OrderedNamespaceResultsMap, color bool) {
+	var unlabeled []string
+	for _, ns := range results.Keys() {
+		if results.Get(ns).LabelStatus == admission.LabelMissing {
+			unlabeled = append(unlabeled, ns)
+		}
+	}
+	if len(unlabeled) == 0 {
+		return
+	}
+	sort.SliceStable(unlabeled, func(i, j int) bool {
+		return results.Get(unlabeled[i]).Level > results.Get(unlabeled[j]).Level
+	})
+
+	fmt.Fprintf(w, "\n%d namespaces without a %s label, effectively privileged:\n", len(unlabeled), psapi.EnforceLevelLabel)
+	for _, ns := range unlabeled {
+		fmt.Fprintf(w, "    %s: could adopt %s\n", ns, colorLevel(results.Get(ns).Level, color))
+	}
+}
@@ -0,0 +1,33 @@
+package printers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// WriteResults prints the results to the OutputFile of the options, or to w if
+// it is not set. The file is only written once the results were printed in
+// full so that a failed run does not leave a truncated report behind, its
+// missing parent directories are created.
+func WriteResults(w io.Writer, results *admission.OrderedNamespaceResultsMap, opts *PrintOptions) error {
+	if len(opts.OutputFile) == 0 {
+		return PrintResults(w, results, opts)
+	}
+
+	var buf bytes.Buffer
+	if err := PrintResults(&buf, results, opts); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.OutputFile), 0755); err != nil {
+		return fmt.Errorf("failed to create the directory of the --output-file: %w", err)
+	}
+	if err := os.WriteFile(opts.OutputFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write the results to the --output-file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,413 @@
+package printers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+const (
+	// FormatHuman is the default human-readable "namespace: level" output
+	FormatHuman = ""
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	// FormatPrometheus prints the namespace levels as Prometheus metrics
+	FormatPrometheus = "prometheus"
+	// FormatTable prints a row per object with aligned columns
+	FormatTable = "table"
+	// FormatJUnit prints a JUnit XML test case per object for CI systems
+	FormatJUnit = "junit"
+	// FormatCSV prints a record per object for spreadsheets
+	FormatCSV = "csv"
+	// FormatExceptions lists the namespaces that do not meet the target
+	// level as exceptions to it, the furthest from it first
+	FormatExceptions = "exceptions"
+)
+
+var supportedFormats = []string{FormatJSON, FormatYAML, FormatPrometheus, FormatTable, FormatJUnit, FormatCSV, FormatExceptions}
+
+func ValidateFormat(format string) error {
+	if format == FormatHuman {
+		return nil
+	}
+	for _, f := range supportedFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q, allowed formats are: %s", format, strings.Join(supportedFormats, ", "))
+}
+
+// ValidateIncludeRaw checks that the raw admission responses are only
+// requested with the outputs that can hold them
+func ValidateIncludeRaw(includeRaw bool, format string) error {
+	if includeRaw && format != FormatJSON && format != FormatYAML {
+		return fmt.Errorf("--include-raw is only supported by the %s and %s outputs", FormatJSON, FormatYAML)
+	}
+	return nil
+}
+
+type PrintOptions struct {
+	Format string
+	// Explain prints the failed PodSecurity checks of each object in the human-readable output
+	Explain bool
+	// GenerateLabels prints Namespace manifests with the PodSecurity labels
+	// of the computed levels instead of the results
+	GenerateLabels bool
+	// PolicyVersions are the versions the levels were evaluated against, the
+	// generated labels pin them
+	PolicyVersions admission.PolicyVersions
+	// Modes are the PodSecurity modes to print the levels for in the human-readable
+	// output, the generated labels include the warn and audit labels if set
+	Modes []admission.Mode
+	// Summary prints the number of namespaces per level after the human-readable output
+	Summary bool
+	// HideCompliant omits the namespaces whose level is not more privileged
+	// than TargetLevel, restricted if it is empty
+	HideCompliant bool
+	TargetLevel   psapi.Level
+	// SortBy orders the namespaces by name or by level, Reverse reverses the order
+	SortBy  string
+	Reverse bool
+	// GroupBy groups the objects by their namespace or by their kind
+	GroupBy string
+	// Quiet suppresses the output of the results
+	Quiet bool
+	// NoHeaders omits the header row of the table output
+	NoHeaders bool
+	// MaxLevel is the level the objects fail the JUnit test cases above, restricted if it is empty
+	MaxLevel psapi.Level
+	// ExitSummary prints a summary line for the scripts to the standard
+	// error output after the results, see PrintExitSummary
+	ExitSummary bool
+	// Template prints the results by the Go template instead of the Format
+	Template *template.Template
+	// IncludeRaw attaches the raw admission responses to the objects of the
+	// JSON and YAML outputs
+	IncludeRaw bool
+	// Color colors the levels in the human-readable and table outputs by
+	// their severity, see ColorEnabled
+	Color bool
+	// OutputFile is the file to write the results to instead of the
+	// standard output, see WriteResults
+	OutputFile string
+}
+
+func PrintResults(w io.Writer, results *admission.OrderedNamespaceResultsMap, opts *PrintOptions) error {
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.GenerateLabels {
+		return printNamespaceLabels(w, results, opts.PolicyVersions, opts.Modes)
+	}
+
+	if opts.HideCompliant {
+		targetLevel := opts.TargetLevel
+		if len(targetLevel) == 0 {
+			targetLevel = psapi.LevelRestricted
+		}
+		results = admission.WithoutCompliant(results, targetLevel)
+	}
+
+	if err := sortResults(results, opts.SortBy, opts.Reverse); err != nil {
+		return err
+	}
+
+	if opts.Template != nil {
+		return printTemplate(w, results, opts.Template)
+	}
+
+	if opts.GroupBy == GroupByKind {
+		if err := ValidateGroupBy(opts.GroupBy, opts.Format); err != nil {
+			return err
+		}
+		if opts.Format == FormatTable {
+			return printTableByKind(w, results, opts.NoHeaders, opts.Color)
+		}
+		printByKind(w, results, opts.Color)
+		return nil
+	}
+
+	switch opts.Format {
+	case FormatHuman:
+		for _, ns := range results.Keys() {
+			nsResult := results.Get(ns)
+			if opts.Explain {
+				fmt.Fprintf(w, "%s: %s%s%s%s%s%s%s\n", ns, describeLevels(nsResult, opts.Modes, opts.Color), describeRequiredBy(nsResult), describePolicyVersion(nsResult), describeWaivedChecks(nsResult), describeLabelStatus(nsResult), describeNamespaceExists(nsResult), describeSCCs(nsResult))
+				if nsResult.OthersLevel != nil {
+					fmt.Fprintf(w, "    %s\n", describeConflict(ns, nsResult))
+				}
+				if len(opts.TargetLevel) > 0 {
+					printTargetLevelChanges(w, nsResult, opts.TargetLevel)
+				} else {
+					printFailedChecks(w, nsResult)
+				}
+			} else {
+				fmt.Fprintf(w, "%s: %s%s%s%s%s%s%s\n", ns, describeLevels(nsResult, opts.Modes, opts.Color), describeRequiredBy(nsResult), describePolicyVersion(nsResult), describeWaivedChecks(nsResult), describeLabelStatus(nsResult), describeNamespaceExists(nsResult), describeSCCs(nsResult))
+			}
+		}
+		printUnlabeledNamespaces(w, results, opts.Color)
+		if opts.Summary {
+			printSummary(w, results)
+		}
+	case FormatJSON:
+		if opts.IncludeRaw {
+			admission.IncludeRawResponses(results)
+		}
+		data, err := json.MarshalIndent(results, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal the results to JSON: %w", err)
+		}
+		fmt.Fprintf(w, "%s\n", data)
+	case FormatYAML:
+		if opts.IncludeRaw {
+			admission.IncludeRawResponses(results)
+		}
+		return printYAML(w, results)
+	case FormatPrometheus:
+		printPrometheusMetrics(w, results)
+	case FormatTable:
+		return printTable(w, results, opts.NoHeaders, opts.Color)
+	case FormatJUnit:
+		return printJUnit(w, results, opts.MaxLevel)
+	case FormatCSV:
+		return printCSV(w, results)
+	case FormatExceptions:
+		printExceptions(w, results, opts.TargetLevel)
+	default:
+		return ValidateFormat(opts.Format)
+	}
+	return nil
+}
+
+func printSummary(w io.Writer, results *admission.OrderedNamespaceResultsMap) {
+	counts := map[admission.Level]int{}
+	for _, ns := range results.Keys() {
+		counts[results.Get(ns).Level]++
+	}
+
+	summary := make([]string, 0, len(counts))
+	for _, level := range []admission.Level{admission.LevelRestrictedValue, admission.LevelBaselineValue, admission.LevelPrivilegedValue} {
+		summary = append(summary, fmt.Sprintf("%s: %d", level, counts[level]))
+	}
+	if unknown := counts[admission.LevelUnknownValue]; unknown > 0 {
+		summary = append(summary, fmt.Sprintf("%s: %d", admission.LevelUnknownValue, unknown))
+	}
+	fmt.Fprintln(w, strings.Join(summary, ", "))
+}
+
+func printFailedChecks(w io.Writer, nsResult *admission.NamespaceResult) {
+	for _, obj := range nsResult.Objects {
+		if len(obj.ResolvedFrom) > 0 {
+			fmt.Fprintf(w, "    %s/%s: resolved from %s\n", obj.Kind, obj.Name, strings.Join(obj.ResolvedFrom, ", "))
+		}
+		if obj.StaticPod {
+			fmt.Fprintf(w, "    %s/%s: static pod, run by the kubelet regardless of the namespace labels, fix its manifest on the node instead\n", obj.Kind, obj.Name)
+		}
+		if obj.RejectedByCurrentLabel {
+			fmt.Fprintf(w, "    %s/%s: already rejected by the current enforce label of the namespace\n", obj.Kind, obj.Name)
+		}
+		if len(obj.Result.Exemption) > 0 {
+			fmt.Fprintf(w, "    %s/%s: exempt by %s\n", obj.Kind, obj.Name, obj.Result.Exemption)
+		}
+		if len(obj.DeprecatedAPI) > 0 {
+			fmt.Fprintf(w, "    %s/%s: %s\n", obj.Kind, obj.Name, obj.DeprecatedAPI)
+		}
+		if len(obj.LevelsByVersion) > 0 {
+			fmt.Fprintf(w, "    %s/%s: %s\n", obj.Kind, obj.Name, describeVersionLevels(obj.LevelsByVersion, false))
+		}
+		if obj.Result.NoContainers {
+			fmt.Fprintf(w, "    %s/%s: no containers to evaluate\n", obj.Kind, obj.Name)
+		}
+		for _, check := range obj.Result.FailedChecks {
+			fmt.Fprintf(w, "    %s/%s: %s requires %s: %s", obj.Kind, obj.Name, check.ID, check.RequiredLevel, check.Reason)
+			if len(check.Containers) > 0 {
+				fmt.Fprintf(w, " in containers %s", strings.Join(check.Containers, ", "))
+			}
+			if len(check.Detail) > 0 {
+				fmt.Fprintf(w, " (%s)", check.Detail)
+			}
+			fmt.Fprintln(w)
+		}
+		for _, check := range obj.Result.WaivedChecks {
+			fmt.Fprintf(w, "    %s/%s: %s waived, would require %s: %s", obj.Kind, obj.Name, check.ID, check.RequiredLevel, check.Reason)
+			if len(check.Detail) > 0 {
+				fmt.Fprintf(w, " (%s)", check.Detail)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// printTargetLevelChanges lists the objects that already meet the target
+// level separately from the ones that need changes to meet it
+func printTargetLevelChanges(w io.Writer, nsResult *admission.NamespaceResult, target psapi.Level) {
+	var compliant []string
+	for _, obj := range nsResult.Objects {
+		if obj.MeetsTargetLevel != nil && *obj.MeetsTargetLevel {
+			compliant = append(compliant, fmt.Sprintf("%s/%s", obj.Kind, obj.Name))
+		}
+	}
+	if len(compliant) > 0 {
+		fmt.Fprintf(w, "    already compliant with %s: %s\n", target, strings.Join(compliant, ", "))
+	}
+
+	for _, obj := range nsResult.Objects {
+		if obj.MeetsTargetLevel == nil || *obj.MeetsTargetLevel {
+			continue
+		}
+		changes := "changes"
+		if len(obj.RequiredChanges) == 1 {
+			changes = "change"
+		}
+		fmt.Fprintf(w, "    %s/%s needs %d %s to meet %s:", obj.Kind, obj.Name, len(obj.RequiredChanges), changes, target)
+		if obj.RejectedByCurrentLabel {
+			fmt.Fprint(w, " (already rejected by the current enforce label of the namespace)")
+		}
+		fmt.Fprintln(w)
+		for _, check := range obj.RequiredChanges {
+			fmt.Fprintf(w, "        %s: %s", check.ID, check.Reason)
+			if len(check.Detail) > 0 {
+				fmt.Fprintf(w, " (%s)", check.Detail)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// describeLevels uses the plain "namespace: level" form when only the enforce
+// level is requested
+func describeLevels(nsResult *admission.NamespaceResult, modes []admission.Mode, color bool) string {
+	if len(nsResult.LevelsByVersion) > 0 {
+		return describeVersionLevels(nsResult.LevelsByVersion, color)
+	}
+	if len(modes) == 0 || (len(modes) == 1 && modes[0] == admission.ModeEnforce) {
+		return colorLevel(nsResult.Level, color)
+	}
+
+	levels := make([]string, 0, len(modes))
+	for _, mode := range modes {
+		levels = append(levels, fmt.Sprintf("%s=%s", mode, colorLevel(nsResult.LevelForMode(mode), color)))
+	}
+	return strings.Join(levels, " ")
+}
+
+// describeVersionLevels lists the levels from the oldest policy version to
+// the latest one, e.g. "v1.22=restricted v1.23=baseline", and points out
+// the levels that differ between the versions
+func describeVersionLevels(levelsByVersion map[string]admission.Level, color bool) string {
+	versions := make([]psapi.Version, 0, len(levelsByVersion))
+	for v := range levelsByVersion {
+		// the keys were formatted from the parsed versions
+		version, _ := psapi.ParseVersion(v)
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Older(versions[j]) })
+
+	levels := make([]string, 0, len(versions))
+	var differ bool
+	for _, version := range versions {
+		level := levelsByVersion[version.String()]
+		differ = differ || level != levelsByVersion[versions[0].String()]
+		levels = append(levels, fmt.Sprintf("%s=%s", version, colorLevel(level, color)))
+	}
+	if differ {
+		return strings.Join(levels, " ") + " (differs between the versions)"
+	}
+	return strings.Join(levels, " ")
+}
+
+func describeRequiredBy(nsResult *admission.NamespaceResult) string {
+	if len(nsResult.RequiredBy) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (required by %s)", strings.Join(nsResult.RequiredBy, ", "))
+}
+
+// describeConflict points out the objects that keep the namespace from
+// being tightened to the level of the other objects
+func describeConflict(ns string, nsResult *admission.NamespaceResult) string {
+	needs := "needs"
+	if len(nsResult.RequiredBy) > 1 {
+		needs = "need"
+	}
+	return fmt.Sprintf("namespace %s must be %q because %s %s it, even though the other workloads would allow %q",
+		ns, nsResult.Level, strings.Join(nsResult.RequiredBy, ", "), needs, *nsResult.OthersLevel)
+}
+
+func describePolicyVersion(nsResult *admission.NamespaceResult) string {
+	if len(nsResult.PolicyVersion) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (policy version %s pinned by the namespace)", nsResult.PolicyVersion)
+}
+
+func describeWaivedChecks(nsResult *admission.NamespaceResult) string {
+	if len(nsResult.WaivedChecks) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (waived: %s)", strings.Join(nsResult.WaivedChecks, ", "))
+}
+
+func describeNamespaceExists(nsResult *admission.NamespaceResult) string {
+	if nsResult.NamespaceExists == nil || *nsResult.NamespaceExists {
+		return ""
+	}
+	return " (namespace does not exist)"
+}
+
+func describeSCCs(nsResult *admission.NamespaceResult) string {
+	if nsResult.SCCs == nil {
+		return ""
+	}
+	if len(nsResult.SCCs) == 0 {
+		return " (SCCs: none)"
+	}
+	return fmt.Sprintf(" (SCCs: %s)", strings.Join(nsResult.SCCs, ", "))
+}
+
+func describeLabelStatus(nsResult *admission.NamespaceResult) string {
+	switch nsResult.LabelStatus {
+	case admission.LabelMatches:
+		return fmt.Sprintf(" (current: %s)", *nsResult.CurrentLevel)
+	case admission.LabelMissing:
+		return " (current: no enforce label)"
+	case admission.LabelInvalid:
+		return " (current: invalid enforce label)"
+	case admission.LabelTooRestrictive:
+		return fmt.Sprintf(" (current: %s, MISMATCH: some workloads would be rejected)", *nsResult.CurrentLevel)
+	case admission.LabelTooPermissive:
+		return fmt.Sprintf(" (current: %s, MISMATCH: the label can be tightened)", *nsResult.CurrentLevel)
+	default:
+		return ""
+	}
+}
+
+// printYAML prints the namespaces one by one in the order of the results,
+// the YAML marshalling would sort the keys of a single mapping by name
+func printYAML(w io.Writer, results *admission.OrderedNamespaceResultsMap) error {
+	keys := results.Keys()
+	if len(keys) == 0 {
+		fmt.Fprintln(w, "{}")
+		return nil
+	}
+	for _, ns := range keys {
+		// goes through the JSON marshalling so that both formats share the same schema
+		data, err := yaml.Marshal(map[string]*admission.NamespaceResult{ns: results.Get(ns)})
+		if err != nil {
+			return fmt.Errorf("failed to marshal the results to YAML: %w", err)
+		}
+		fmt.Fprintf(w, "%s", data)
+	}
+	return nil
+}
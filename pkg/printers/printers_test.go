@@ -0,0 +1,74 @@
+package printers
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+func TestPrintResultsOrder(t *testing.T) {
+	newResults := func() *admission.OrderedNamespaceResultsMap {
+		return admission.NewOrderedNamespaceResultsMap(map[string]*admission.NamespaceResult{
+			"web":  {Level: admission.LevelBaselineValue},
+			"db":   {Level: admission.LevelPrivilegedValue},
+			"docs": {Level: admission.LevelRestrictedValue},
+		})
+	}
+
+	tests := []struct {
+		name     string
+		sortBy   string
+		reverse  bool
+		expected []string
+	}{
+		{name: "by name", sortBy: SortByName, expected: []string{"db", "docs", "web"}},
+		{name: "by level", sortBy: SortByLevel, expected: []string{"db", "web", "docs"}},
+		{name: "by level reversed", sortBy: SortByLevel, reverse: true, expected: []string{"docs", "web", "db"}},
+	}
+	for _, tt := range tests {
+		for _, format := range []string{FormatJSON, FormatYAML} {
+			t.Run(tt.name+" "+format, func(t *testing.T) {
+				out := &bytes.Buffer{}
+				if err := PrintResults(out, newResults(), &PrintOptions{Format: format, SortBy: tt.sortBy, Reverse: tt.reverse}); err != nil {
+					t.Fatalf("failed to print the results: %v", err)
+				}
+
+				// the namespaces are the only keys at the top level of both outputs
+				var namespaces []string
+				for _, line := range strings.Split(out.String(), "\n") {
+					switch {
+					case format == FormatJSON && strings.HasPrefix(line, `    "`) && !strings.HasPrefix(line, `     `):
+						namespaces = append(namespaces, strings.SplitN(line, `"`, 3)[1])
+					case format == FormatYAML && strings.HasSuffix(line, ":") && !strings.HasPrefix(line, " "):
+						namespaces = append(namespaces, strings.TrimSuffix(line, ":"))
+					}
+				}
+				if !reflect.DeepEqual(namespaces, tt.expected) {
+					t.Errorf("expected the namespaces in the order %v, got %v in:\n%s", tt.expected, namespaces, out.String())
+				}
+			})
+		}
+	}
+}
+
+func TestPrintResultsRequiredBy(t *testing.T) {
+	for _, explain := range []bool{false, true} {
+		results := admission.NewOrderedNamespaceResultsMap(nil)
+		results.Set("apps", &admission.NamespaceResult{Level: admission.LevelBaselineValue, RequiredBy: []string{"Deployment/web", "Pod/debug"}})
+		results.Set("docs", &admission.NamespaceResult{Level: admission.LevelRestrictedValue})
+
+		out := &bytes.Buffer{}
+		if err := PrintResults(out, results, &PrintOptions{Format: FormatHuman, Explain: explain}); err != nil {
+			t.Fatalf("failed to print the results: %v", err)
+		}
+		if expected := "apps: baseline (required by Deployment/web, Pod/debug)\n"; !strings.Contains(out.String(), expected) {
+			t.Errorf("expected the output with explain=%t to contain %q, got:\n%s", explain, expected, out.String())
+		}
+		if expected := "docs: restricted\n"; !strings.Contains(out.String(), expected) {
+			t.Errorf("expected the output with explain=%t to contain %q, got:\n%s", explain, expected, out.String())
+		}
+	}
+}
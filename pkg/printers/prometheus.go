@@ -0,0 +1,29 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+const namespaceLevelMetric = "psachecker_namespace_required_level"
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// printPrometheusMetrics prints the level of each namespace as a gauge in the
+// Prometheus text exposition format
+func printPrometheusMetrics(w io.Writer, results *admission.OrderedNamespaceResultsMap) {
+	fmt.Fprintf(w, "# HELP %s The least privileged PodSecurity level that allows all the workloads of the namespace: %d=privileged, %d=baseline, %d=restricted, %d=unknown.\n",
+		namespaceLevelMetric,
+		admission.LevelPrivilegedValue,
+		admission.LevelBaselineValue,
+		admission.LevelRestrictedValue,
+		admission.LevelUnknownValue,
+	)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", namespaceLevelMetric)
+	for _, ns := range results.Keys() {
+		fmt.Fprintf(w, "%s{namespace=\"%s\"} %d\n", namespaceLevelMetric, labelValueEscaper.Replace(ns), int(results.Get(ns).Level))
+	}
+}
@@ -0,0 +1,52 @@
+package printers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+const (
+	SortByName = "name"
+	// SortByLevel puts the namespaces that require the most privileged levels first
+	SortByLevel = "level"
+)
+
+var supportedSortKeys = []string{SortByName, SortByLevel}
+
+func ValidateSortBy(sortBy string) error {
+	for _, k := range supportedSortKeys {
+		if k == sortBy {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported sort key %q, allowed keys are: %s", sortBy, strings.Join(supportedSortKeys, ", "))
+}
+
+// sortResults orders the namespaces of the results, the namespaces with
+// the same level are ordered by their names
+func sortResults(results *admission.OrderedNamespaceResultsMap, sortBy string, reverse bool) error {
+	var less func(a, b string) bool
+	switch sortBy {
+	case SortByName, "":
+		less = func(a, b string) bool { return a < b }
+	case SortByLevel:
+		less = func(a, b string) bool {
+			levelA, levelB := results.Get(a).Level, results.Get(b).Level
+			if levelA != levelB {
+				return levelA < levelB
+			}
+			return a < b
+		}
+	default:
+		return ValidateSortBy(sortBy)
+	}
+
+	if reverse {
+		results.SortFunc(func(a, b string) bool { return less(b, a) })
+	} else {
+		results.SortFunc(less)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+type tableRow struct {
+	namespace, kind, name string
+	level                 admission.Level
+}
+
+// printTable prints a row per evaluated object, the namespaces without any
+// objects get a single row with their level. The level is the last column so
+// that the color codes don't break the alignment.
+func printTable(w io.Writer, results *admission.OrderedNamespaceResultsMap, noHeaders, color bool) error {
+	var rows []tableRow
+	for _, ns := range results.Keys() {
+		nsResult := results.Get(ns)
+		if len(nsResult.Objects) == 0 {
+			rows = append(rows, tableRow{namespace: ns, kind: "-", name: "-", level: nsResult.Level})
+			continue
+		}
+		nsRows := make([]tableRow, 0, len(nsResult.Objects))
+		for _, obj := range nsResult.Objects {
+			nsRows = append(nsRows, tableRow{namespace: ns, kind: obj.Kind, name: obj.Name, level: obj.Result.Level()})
+		}
+		// the namespaces keep their order, the most privileged workloads of each go first
+		sort.SliceStable(nsRows, func(i, j int) bool {
+			return nsRows[i].level < nsRows[j].level
+		})
+		rows = append(rows, nsRows...)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	if !noHeaders {
+		fmt.Fprintln(tw, "NAMESPACE\tKIND\tNAME\tREQUIRED LEVEL")
+	}
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", row.namespace, row.kind, row.name, colorLevel(row.level, color))
+	}
+	return tw.Flush()
+}
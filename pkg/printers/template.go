@@ -0,0 +1,42 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// templateData is what the --template is executed against, the namespaces
+// keep the order of the results
+type templateData struct {
+	Namespaces []templateNamespace
+}
+
+// templateNamespace exposes the fields of the namespace result, the same
+// ones that back the JSON and YAML outputs, along with the name
+type templateNamespace struct {
+	Name string
+	*admission.NamespaceResult
+}
+
+// ParseTemplate parses the Go template of the --template flag
+func ParseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --template value: %w", err)
+	}
+	return tmpl, nil
+}
+
+func printTemplate(w io.Writer, results *admission.OrderedNamespaceResultsMap, tmpl *template.Template) error {
+	data := templateData{Namespaces: make([]templateNamespace, 0, len(results.Keys()))}
+	for _, ns := range results.Keys() {
+		data.Namespaces = append(data.Namespaces, templateNamespace{Name: ns, NamespaceResult: results.Get(ns)})
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to execute the --template: %w", err)
+	}
+	return nil
+}
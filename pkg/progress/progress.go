@@ -0,0 +1,65 @@
+// Package progress reports how many of the objects or namespaces were
+// evaluated so far during long scans
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+// Reporter writes the progress counter to w, which should not be the writer
+// of the results. On a terminal the counter is rewritten in place and erased
+// once done, otherwise a line is written for every tenth of the scan.
+type Reporter struct {
+	w        io.Writer
+	terminal bool
+
+	lock    sync.Mutex
+	printed int
+}
+
+func NewReporter(w io.Writer) *Reporter {
+	return &Reporter{
+		w:        w,
+		terminal: term.IsTerminal(w),
+	}
+}
+
+// Enabled returns the value of the --progress flag if it was set, otherwise
+// the progress is reported if the error output of the command is a terminal
+func Enabled(cmd *cobra.Command) bool {
+	if cmd.Flags().Changed("progress") {
+		return cmdutil.GetFlagBool(cmd, "progress")
+	}
+	return term.IsTerminal(cmd.ErrOrStderr())
+}
+
+// Report is safe to be called by multiple workers, done should be increasing
+// for each of the scans
+func (r *Reporter) Report(done, total int, what string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.terminal {
+		if done < total {
+			fmt.Fprintf(r.w, "\rchecked %d/%d %s", done, total, what)
+		} else {
+			// erase the line so that it does not get mixed with the output
+			fmt.Fprint(r.w, "\r\033[K")
+		}
+		return
+	}
+
+	if step := done * 10 / total; step > r.printed {
+		fmt.Fprintf(r.w, "checked %d/%d %s\n", done, total, what)
+		r.printed = step
+	}
+	if done == total {
+		r.printed = 0
+	}
+}
@@ -0,0 +1,50 @@
+package scandiff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+func NewDiffCommand() *cobra.Command {
+	var failOnIncrease bool
+
+	cmd := &cobra.Command{
+		Use:          "diff OLD.json NEW.json",
+		Short:        "compare the namespace levels of two scans saved with \"-o json\"",
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			oldScan, err := readScan(args[0])
+			if err != nil {
+				return err
+			}
+			newScan, err := readScan(args[1])
+			if err != nil {
+				return err
+			}
+
+			changes := diffScans(oldScan, newScan)
+			printChanges(c.OutOrStdout(), changes)
+
+			if failOnIncrease {
+				var increased []string
+				for _, change := range changes {
+					if change.Increased() {
+						increased = append(increased, change.Namespace)
+					}
+				}
+				if len(increased) > 0 {
+					return &admission.PolicyError{Err: fmt.Errorf("namespaces require a more privileged level than before: %s", strings.Join(increased, ", "))}
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&failOnIncrease, "fail-on-increase", false, "Fail if any namespace requires a more privileged level than in the old scan.")
+	return cmd
+}
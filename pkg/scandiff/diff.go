@@ -0,0 +1,86 @@
+package scandiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// scanResult is the part of the JSON output of a scan that the diff compares
+type scanResult struct {
+	Level admission.Level `json:"level"`
+}
+
+// levelChange is a namespace whose level differs between two scans, the
+// old or the new level is nil if the namespace was added or removed
+type levelChange struct {
+	Namespace string
+	OldLevel  *admission.Level
+	NewLevel  *admission.Level
+}
+
+// Increased returns true if the namespace requires a more privileged level
+// than before, added namespaces count as increased unless they are restricted
+func (c *levelChange) Increased() bool {
+	if c.NewLevel == nil {
+		return false
+	}
+	if c.OldLevel == nil {
+		return *c.NewLevel != admission.LevelRestrictedValue
+	}
+	return *c.NewLevel < *c.OldLevel
+}
+
+func readScan(path string) (map[string]*scanResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[string]*scanResult{}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse %q, expected the JSON output of a scan: %w", path, err)
+	}
+	return results, nil
+}
+
+// diffScans returns the changes between the scans, sorted by namespace
+func diffScans(oldScan, newScan map[string]*scanResult) []*levelChange {
+	var changes []*levelChange
+	for ns, oldResult := range oldScan {
+		newResult, ok := newScan[ns]
+		switch {
+		case !ok:
+			changes = append(changes, &levelChange{Namespace: ns, OldLevel: &oldResult.Level})
+		case newResult.Level != oldResult.Level:
+			changes = append(changes, &levelChange{Namespace: ns, OldLevel: &oldResult.Level, NewLevel: &newResult.Level})
+		}
+	}
+	for ns, newResult := range newScan {
+		if _, ok := oldScan[ns]; !ok {
+			changes = append(changes, &levelChange{Namespace: ns, NewLevel: &newResult.Level})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Namespace < changes[j].Namespace
+	})
+	return changes
+}
+
+func printChanges(w io.Writer, changes []*levelChange) {
+	for _, change := range changes {
+		switch {
+		case change.OldLevel == nil:
+			fmt.Fprintf(w, "+ %s: %s\n", change.Namespace, *change.NewLevel)
+		case change.NewLevel == nil:
+			fmt.Fprintf(w, "- %s: %s\n", change.Namespace, *change.OldLevel)
+		default:
+			fmt.Fprintf(w, "~ %s: %s -> %s\n", change.Namespace, *change.OldLevel, *change.NewLevel)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package scandiff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+func levelPtr(level admission.Level) *admission.Level {
+	return &level
+}
+
+func TestDiffScans(t *testing.T) {
+	oldScan := map[string]*scanResult{
+		"apps":       {Level: admission.LevelBaselineValue},
+		"db":         {Level: admission.LevelBaselineValue},
+		"monitoring": {Level: admission.LevelPrivilegedValue},
+		"legacy":     {Level: admission.LevelPrivilegedValue},
+		"docs":       {Level: admission.LevelRestrictedValue},
+	}
+	newScan := map[string]*scanResult{
+		"apps":       {Level: admission.LevelBaselineValue},
+		"db":         {Level: admission.LevelPrivilegedValue},
+		"monitoring": {Level: admission.LevelBaselineValue},
+		"docs":       {Level: admission.LevelRestrictedValue},
+		"batch":      {Level: admission.LevelBaselineValue},
+		"web":        {Level: admission.LevelRestrictedValue},
+	}
+
+	expected := []struct {
+		namespace string
+		oldLevel  *admission.Level
+		newLevel  *admission.Level
+		increased bool
+	}{
+		{namespace: "batch", newLevel: levelPtr(admission.LevelBaselineValue), increased: true},
+		{namespace: "db", oldLevel: levelPtr(admission.LevelBaselineValue), newLevel: levelPtr(admission.LevelPrivilegedValue), increased: true},
+		{namespace: "legacy", oldLevel: levelPtr(admission.LevelPrivilegedValue)},
+		{namespace: "monitoring", oldLevel: levelPtr(admission.LevelPrivilegedValue), newLevel: levelPtr(admission.LevelBaselineValue)},
+		// the added namespaces that are restricted don't need anything more
+		{namespace: "web", newLevel: levelPtr(admission.LevelRestrictedValue)},
+	}
+
+	changes := diffScans(oldScan, newScan)
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d", len(expected), len(changes))
+	}
+	for i, change := range changes {
+		tt := expected[i]
+		if change.Namespace != tt.namespace {
+			t.Errorf("expected the change %d to be of %s, got %s", i, tt.namespace, change.Namespace)
+			continue
+		}
+		if !equalLevels(change.OldLevel, tt.oldLevel) || !equalLevels(change.NewLevel, tt.newLevel) {
+			t.Errorf("expected %s to change from %v to %v, got from %v to %v", tt.namespace, tt.oldLevel, tt.newLevel, change.OldLevel, change.NewLevel)
+		}
+		if increased := change.Increased(); increased != tt.increased {
+			t.Errorf("expected %s to be increased=%t, got %t", tt.namespace, tt.increased, increased)
+		}
+	}
+}
+
+func equalLevels(a, b *admission.Level) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func TestLevelChangeIncreased(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   levelChange
+		expected bool
+	}{
+		{
+			name:     "added baseline",
+			change:   levelChange{NewLevel: levelPtr(admission.LevelBaselineValue)},
+			expected: true,
+		},
+		{
+			name:     "added privileged",
+			change:   levelChange{NewLevel: levelPtr(admission.LevelPrivilegedValue)},
+			expected: true,
+		},
+		{
+			name:   "added restricted",
+			change: levelChange{NewLevel: levelPtr(admission.LevelRestrictedValue)},
+		},
+		{
+			name:   "removed privileged",
+			change: levelChange{OldLevel: levelPtr(admission.LevelPrivilegedValue)},
+		},
+		{
+			name:     "raised to privileged",
+			change:   levelChange{OldLevel: levelPtr(admission.LevelRestrictedValue), NewLevel: levelPtr(admission.LevelPrivilegedValue)},
+			expected: true,
+		},
+		{
+			name:   "lowered to restricted",
+			change: levelChange{OldLevel: levelPtr(admission.LevelBaselineValue), NewLevel: levelPtr(admission.LevelRestrictedValue)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if increased := tt.change.Increased(); increased != tt.expected {
+				t.Errorf("expected increased=%t, got %t", tt.expected, increased)
+			}
+		})
+	}
+}
+
+func TestReadScan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.json")
+	scan := `{"apps": {"level": "baseline", "objects": []}, "docs": {"level": "restricted", "objects": []}}`
+	if err := os.WriteFile(path, []byte(scan), 0600); err != nil {
+		t.Fatalf("failed to write the scan: %v", err)
+	}
+
+	results, err := readScan(path)
+	if err != nil {
+		t.Fatalf("failed to read the scan: %v", err)
+	}
+	expected := map[string]admission.Level{"apps": admission.LevelBaselineValue, "docs": admission.LevelRestrictedValue}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d namespaces, got %d", len(expected), len(results))
+	}
+	for ns, level := range expected {
+		if result, ok := results[ns]; !ok || result.Level != level {
+			t.Errorf("expected %s to be %s, got %v", ns, level, result)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/stlaz/psachecker/pkg/checker"
+)
+
+func NewWatchCommand(clientConfigOptions *genericclioptions.ConfigFlags) *cobra.Command {
+	o := newWatchOptions()
+
+	cmd := &cobra.Command{
+		Use:          "watch [-A] [flags]",
+		Short:        "keep evaluating the workloads in the cluster as they change and print the namespaces whose required PodSecurity level shifts",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.Complete(c, clientConfigOptions); err != nil {
+				return err
+			}
+			errs := o.Validate()
+			if len(errs) > 0 {
+				return fmt.Errorf("there were errors while setting up the command: %v", errs)
+			}
+
+			podSecurityChecker, err := checker.NewChecker(o.kubeClient, o.checkOptions)
+			if err != nil {
+				return err
+			}
+
+			w, err := newWatcher(o.kubeClient, o.namespace, podSecurityChecker, c.OutOrStdout())
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return w.run(ctx, o.workers)
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,98 @@
+package watch
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	psadmissionapi "k8s.io/pod-security-admission/admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/checker"
+	"github.com/stlaz/psachecker/pkg/config"
+	"github.com/stlaz/psachecker/pkg/kubecontexts"
+)
+
+type WatchOptions struct {
+	allNamespaces bool
+	namespace     string
+	// workers is the number of the objects evaluated at the same time
+	workers      int
+	checkOptions *checker.Options
+
+	kubeClient kubernetes.Interface
+}
+
+func newWatchOptions() *WatchOptions {
+	return &WatchOptions{
+		checkOptions: &checker.Options{},
+	}
+}
+
+func (o *WatchOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "Watch the workloads in all namespaces of the cluster.")
+}
+
+func (o *WatchOptions) Complete(cmd *cobra.Command, clientConfigOptions *genericclioptions.ConfigFlags) error {
+	// the global --context can be repeated for the inspections of several clusters
+	contexts := cmdutil.GetFlagStringArray(cmd, "context")
+	if len(contexts) > 1 {
+		return fmt.Errorf("%s supports a single --context, got %d", cmd.Name(), len(contexts))
+	}
+	clientConfigOptions = kubecontexts.ConfigFlags(clientConfigOptions, contexts)[0]
+
+	clientConfig, err := clientConfigOptions.ToRawKubeConfigLoader().ClientConfig()
+	if err != nil {
+		return err
+	}
+	if o.kubeClient, err = kubernetes.NewForConfig(clientConfig); err != nil {
+		return err
+	}
+	if !o.allNamespaces {
+		if o.namespace, _, err = clientConfigOptions.ToRawKubeConfigLoader().Namespace(); err != nil {
+			return err
+		}
+	}
+
+	psaConfig, err := config.LoadPodSecurityConfig(cmd)
+	if err != nil {
+		return err
+	}
+	versionValue := cmdutil.GetFlagString(cmd, "policy-version")
+	policyVersion, fromServer, err := admission.ParsePolicyVersion(versionValue, o.kubeClient.Discovery(), cmdutil.GetFlagInt(cmd, "max-retries"))
+	if err != nil {
+		return fmt.Errorf("failed to resolve --policy-version=%s: %w", versionValue, err)
+	}
+	if fromServer {
+		klog.InfoS("Evaluating against the PodSecurity policy version of the server", "version", policyVersion)
+	}
+	// only the enforce level is reported
+	o.checkOptions.PolicyVersions = admission.PolicyVersions{Enforce: policyVersion, Warn: policyVersion, Audit: policyVersion}
+	// the objects are evaluated one by one, by as many workers as the concurrency allows
+	o.workers = cmdutil.GetFlagInt(cmd, "max-concurrency")
+	o.checkOptions.MaxConcurrency = 1
+	o.checkOptions.Exemptions = psadmissionapi.PodSecurityExemptions{
+		Namespaces:     cmdutil.GetFlagStringSlice(cmd, "exempt-namespace"),
+		RuntimeClasses: cmdutil.GetFlagStringSlice(cmd, "exempt-runtime-class"),
+		Usernames:      cmdutil.GetFlagStringSlice(cmd, "exempt-user"),
+	}
+	if psaConfig != nil {
+		o.checkOptions.Exemptions = admission.MergeExemptions(o.checkOptions.Exemptions, psaConfig.Exemptions)
+	}
+	o.checkOptions.IgnoredChecks = cmdutil.GetFlagStringSlice(cmd, "ignore-control")
+	return nil
+}
+
+func (o *WatchOptions) Validate() []error {
+	var errs []error
+
+	if o.workers < 1 {
+		errs = append(errs, fmt.Errorf("--max-concurrency must be a positive number"))
+	}
+
+	return errs
+}
@@ -0,0 +1,113 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+- name: prod
+  cluster:
+    server: https://prod.example.com
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: admin
+    namespace: dev-apps
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+    namespace: prod-apps
+current-context: dev
+`
+
+// newTestCommand returns the watch command with the global flags it reads
+// and the client config flags of the test kubeconfig
+func newTestCommand(t *testing.T, o *WatchOptions, args ...string) (*cobra.Command, *genericclioptions.ConfigFlags) {
+	t.Helper()
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0600); err != nil {
+		t.Fatalf("failed to write the kubeconfig: %v", err)
+	}
+	clientConfigOptions := genericclioptions.NewConfigFlags(true)
+	clientConfigOptions.KubeConfig = &kubeconfig
+	clientConfigOptions.Context = nil
+
+	cmd := &cobra.Command{Use: "watch"}
+	o.AddFlags(cmd)
+	flags := cmd.Flags()
+	flags.StringArray("context", nil, "")
+	flags.String("policy-version", "latest", "")
+	flags.String("psa-config", "", "")
+	flags.Int("max-retries", 0, "")
+	flags.Int("max-concurrency", 1, "")
+	flags.StringSlice("exempt-namespace", nil, "")
+	flags.StringSlice("exempt-runtime-class", nil, "")
+	flags.StringSlice("exempt-user", nil, "")
+	flags.StringSlice("ignore-control", nil, "")
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("failed to parse the flags: %v", err)
+	}
+	return cmd, clientConfigOptions
+}
+
+func TestCompleteContext(t *testing.T) {
+	tests := []struct {
+		name              string
+		args              []string
+		expectedNamespace string
+		expectErr         bool
+	}{
+		{
+			name:              "current context",
+			expectedNamespace: "dev-apps",
+		},
+		{
+			name:              "selected context",
+			args:              []string{"--context=prod"},
+			expectedNamespace: "prod-apps",
+		},
+		{
+			name:      "several contexts",
+			args:      []string{"--context=dev", "--context=prod"},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newWatchOptions()
+			cmd, clientConfigOptions := newTestCommand(t, o, tt.args...)
+
+			err := o.Complete(cmd, clientConfigOptions)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error for %v", tt.args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to complete the options: %v", err)
+			}
+			if o.namespace != tt.expectedNamespace {
+				t.Errorf("expected to watch the namespace %q, got %q", tt.expectedNamespace, o.namespace)
+			}
+		})
+	}
+}
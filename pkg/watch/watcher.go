@@ -0,0 +1,226 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/checker"
+)
+
+// maxRequeues is how many times the evaluation of an object is retried
+// before it is given up until the object changes again
+const maxRequeues = 5
+
+// workloadResources are the watched resources with a pod spec, mapped to
+// their kinds as the informers don't set those on the objects
+var workloadResources = map[schema.GroupVersionResource]schema.GroupVersionKind{
+	corev1.SchemeGroupVersion.WithResource("pods"):                   corev1.SchemeGroupVersion.WithKind("Pod"),
+	corev1.SchemeGroupVersion.WithResource("replicationcontrollers"): corev1.SchemeGroupVersion.WithKind("ReplicationController"),
+	corev1.SchemeGroupVersion.WithResource("podtemplates"):           corev1.SchemeGroupVersion.WithKind("PodTemplate"),
+	appsv1.SchemeGroupVersion.WithResource("replicasets"):            appsv1.SchemeGroupVersion.WithKind("ReplicaSet"),
+	appsv1.SchemeGroupVersion.WithResource("deployments"):            appsv1.SchemeGroupVersion.WithKind("Deployment"),
+	appsv1.SchemeGroupVersion.WithResource("statefulsets"):           appsv1.SchemeGroupVersion.WithKind("StatefulSet"),
+	appsv1.SchemeGroupVersion.WithResource("daemonsets"):             appsv1.SchemeGroupVersion.WithKind("DaemonSet"),
+	batchv1.SchemeGroupVersion.WithResource("jobs"):                  batchv1.SchemeGroupVersion.WithKind("Job"),
+	batchv1.SchemeGroupVersion.WithResource("cronjobs"):              batchv1.SchemeGroupVersion.WithKind("CronJob"),
+}
+
+// watcher keeps the results of the workloads up to date as they change and
+// reports the namespaces whose required level shifts
+type watcher struct {
+	checker   *checker.Checker
+	factory   informers.SharedInformerFactory
+	informers map[schema.GroupVersionKind]cache.SharedIndexInformer
+	queue     workqueue.RateLimitingInterface
+	out       io.Writer
+
+	// lock guards the results and the levels, it is also held while
+	// printing so that the lines of the workers don't interleave
+	lock    sync.Mutex
+	results map[string]admission.AdmissionResultsMap
+	levels  map[string]admission.Level
+}
+
+// newWatcher watches the workloads of the namespace, of all namespaces if it
+// is empty
+func newWatcher(kubeClient kubernetes.Interface, namespace string, podSecurityChecker *checker.Checker, out io.Writer) (*watcher, error) {
+	w := &watcher{
+		checker:   podSecurityChecker,
+		factory:   informers.NewSharedInformerFactoryWithOptions(kubeClient, 0, informers.WithNamespace(namespace)),
+		informers: map[schema.GroupVersionKind]cache.SharedIndexInformer{},
+		queue:     workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		out:       out,
+		results:   map[string]admission.AdmissionResultsMap{},
+		levels:    map[string]admission.Level{},
+	}
+
+	for gvr, gvk := range workloadResources {
+		gvk := gvk
+		informer, err := w.factory.ForResource(gvr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up the informer of %s: %w", gvr.Resource, err)
+		}
+		informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { w.enqueue(gvk, obj) },
+			UpdateFunc: func(_, obj interface{}) { w.enqueue(gvk, obj) },
+			DeleteFunc: func(obj interface{}) { w.enqueue(gvk, obj) },
+		})
+		w.informers[gvk] = informer.Informer()
+	}
+	return w, nil
+}
+
+func (w *watcher) enqueue(gvk schema.GroupVersionKind, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("cannot enqueue %T: %w", obj, err))
+		return
+	}
+	w.queue.Add(admission.AdmissionResultsKey{GVK: gvk, Namespace: objMeta.GetNamespace(), Name: objMeta.GetName()})
+}
+
+// run evaluates the changed objects by the given number of workers until
+// the context is canceled
+func (w *watcher) run(ctx context.Context, workers int) error {
+	defer w.queue.ShutDown()
+
+	w.factory.Start(ctx.Done())
+	for gvk, synced := range w.factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to list the %s objects", gvk)
+		}
+	}
+	klog.V(2).InfoS("Watching the workloads", "workers", workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.UntilWithContext(ctx, func(ctx context.Context) {
+				for w.processNextItem(ctx) {
+				}
+			}, time.Second)
+		}()
+	}
+
+	<-ctx.Done()
+	w.queue.ShutDown()
+	wg.Wait()
+	return nil
+}
+
+func (w *watcher) processNextItem(ctx context.Context) bool {
+	item, quit := w.queue.Get()
+	if quit {
+		return false
+	}
+	defer w.queue.Done(item)
+
+	key := item.(admission.AdmissionResultsKey)
+	if err := w.sync(ctx, key); err != nil {
+		if w.queue.NumRequeues(key) < maxRequeues {
+			klog.V(2).InfoS("Failed to evaluate the object, retrying", "kind", key.GVK.Kind, "namespace", key.Namespace, "name", key.Name, "err", err)
+			w.queue.AddRateLimited(key)
+			return true
+		}
+		utilruntime.HandleError(fmt.Errorf("failed to evaluate %s/%s in namespace %q: %w", key.GVK.Kind, key.Name, key.Namespace, err))
+	}
+	w.queue.Forget(key)
+	return true
+}
+
+// sync evaluates the current state of the object and updates the level of
+// its namespace, the objects no longer in the cache are dropped from it
+func (w *watcher) sync(ctx context.Context, key admission.AdmissionResultsKey) error {
+	cached, exists, err := w.informers[key.GVK].GetIndexer().GetByKey(key.Namespace + "/" + key.Name)
+	if err != nil {
+		return err
+	}
+
+	var result *admission.ParallelAdmissionResult
+	if exists {
+		obj := cached.(runtime.Object).DeepCopyObject()
+		obj.GetObjectKind().SetGroupVersionKind(key.GVK)
+		results, err := w.checker.Check(ctx, []runtime.Object{obj})
+		if err != nil {
+			return err
+		}
+		result = results[key]
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	nsResults := w.results[key.Namespace]
+	if result != nil {
+		if nsResults == nil {
+			nsResults = admission.AdmissionResultsMap{}
+			w.results[key.Namespace] = nsResults
+		}
+		nsResults[key] = result
+	} else {
+		delete(nsResults, key)
+	}
+	w.updateLevel(key.Namespace)
+	return nil
+}
+
+// updateLevel recomputes the level required by the namespace and prints
+// it if it changed. The caller must hold the lock.
+func (w *watcher) updateLevel(namespace string) {
+	oldLevel, known := w.levels[namespace]
+
+	nsResults := w.results[namespace]
+	if len(nsResults) == 0 {
+		delete(w.results, namespace)
+		delete(w.levels, namespace)
+		if known && oldLevel != admission.LevelRestrictedValue {
+			w.printf("%s: %s -> %s (no workloads left)\n", namespace, oldLevel, admission.LevelRestrictedValue)
+		}
+		return
+	}
+
+	nsResult := admission.AggregateResultsPerNamespace(nsResults)[namespace]
+	w.levels[namespace] = nsResult.Level
+	var requiredBy string
+	if len(nsResult.RequiredBy) > 0 {
+		requiredBy = fmt.Sprintf(" (required by %s)", strings.Join(nsResult.RequiredBy, ", "))
+	}
+
+	switch {
+	case !known:
+		w.printf("%s: %s%s\n", namespace, nsResult.Level, requiredBy)
+	case oldLevel != nsResult.Level:
+		w.printf("%s: %s -> %s%s\n", namespace, oldLevel, nsResult.Level, requiredBy)
+	}
+}
+
+func (w *watcher) printf(format string, args ...interface{}) {
+	fmt.Fprintf(w.out, time.Now().Format(time.RFC3339)+" "+format, args...)
+}
@@ -0,0 +1,107 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	psapi "k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/checker"
+)
+
+// syncBuffer lets the test read the output while the workers print to it
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func newPod(name string, privileged bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: name},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: name, Image: "busybox"}},
+		},
+	}
+	if privileged {
+		pod.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{Privileged: pointer.Bool(true)}
+	}
+	return pod
+}
+
+func TestWatcherLevelShifts(t *testing.T) {
+	client := fake.NewSimpleClientset(newPod("web", false))
+	latest := psapi.LatestVersion()
+	podSecurityChecker, err := checker.NewChecker(client, &checker.Options{
+		ParallelAdmissionOptions: admission.ParallelAdmissionOptions{
+			PolicyVersions: admission.PolicyVersions{Enforce: latest, Warn: latest, Audit: latest},
+			MaxConcurrency: 1,
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to set up the checker: %v", err)
+	}
+
+	out := &syncBuffer{}
+	w, err := newWatcher(client, "", podSecurityChecker, out)
+	if err != nil {
+		t.Fatalf("failed to set up the watcher: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.run(ctx, 1) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("the watcher failed: %v", err)
+		}
+	}()
+
+	waitForLine := func(expected string) {
+		t.Helper()
+		err := wait.PollImmediate(10*time.Millisecond, 10*time.Second, func() (bool, error) {
+			return strings.Contains(out.String(), expected), nil
+		})
+		if err != nil {
+			t.Fatalf("expected the output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+
+	waitForLine("apps: baseline (required by Pod/web)\n")
+
+	if _, err := client.CoreV1().Pods("apps").Create(ctx, newPod("debug", true), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create the pod: %v", err)
+	}
+	waitForLine("apps: baseline -> privileged (required by Pod/debug)\n")
+
+	if err := client.CoreV1().Pods("apps").Delete(ctx, "debug", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete the pod: %v", err)
+	}
+	waitForLine("apps: privileged -> baseline (required by Pod/web)\n")
+
+	if err := client.CoreV1().Pods("apps").Delete(ctx, "web", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete the pod: %v", err)
+	}
+	waitForLine("apps: baseline -> restricted (no workloads left)\n")
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/klog/v2"
+
+	"github.com/stlaz/psachecker/pkg/checker"
+)
+
+func NewServeCommand(clientConfigOptions *genericclioptions.ConfigFlags) *cobra.Command {
+	o := newServeOptions()
+
+	cmd := &cobra.Command{
+		Use:          "serve [flags]",
+		Short:        "run a validating admission webhook that warns about the objects that need a more privileged PodSecurity level than restricted",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.Complete(c, clientConfigOptions); err != nil {
+				return err
+			}
+			errs := o.Validate()
+			if len(errs) > 0 {
+				return fmt.Errorf("there were errors while setting up the command: %v", errs)
+			}
+
+			podSecurityChecker, err := checker.NewChecker(o.kubeClient, o.checkOptions)
+			if err != nil {
+				return err
+			}
+
+			mux := http.NewServeMux()
+			mux.Handle("/validate", newServer(podSecurityChecker))
+			mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			httpServer := &http.Server{
+				Addr:    o.bindAddress,
+				Handler: mux,
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				if err := httpServer.Shutdown(shutdownCtx); err != nil {
+					klog.ErrorS(err, "Failed to shut down the server")
+				}
+			}()
+
+			klog.InfoS("Serving the webhook", "address", o.bindAddress)
+			if err := httpServer.ListenAndServeTLS(o.certFile, o.keyFile); err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	psadmissionapi "k8s.io/pod-security-admission/admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/checker"
+	"github.com/stlaz/psachecker/pkg/config"
+	"github.com/stlaz/psachecker/pkg/kubecontexts"
+)
+
+type ServeOptions struct {
+	bindAddress  string
+	certFile     string
+	keyFile      string
+	checkOptions *checker.Options
+
+	kubeClient kubernetes.Interface
+}
+
+func newServeOptions() *ServeOptions {
+	return &ServeOptions{
+		checkOptions: &checker.Options{},
+	}
+}
+
+func (o *ServeOptions) AddFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.StringVar(&o.bindAddress, "bind-address", ":8443", "The address to serve the webhook on.")
+	flags.StringVar(&o.certFile, "tls-cert-file", "", "The file with the serving certificate.")
+	flags.StringVar(&o.keyFile, "tls-private-key-file", "", "The file with the private key of the serving certificate.")
+}
+
+func (o *ServeOptions) Complete(cmd *cobra.Command, clientConfigOptions *genericclioptions.ConfigFlags) error {
+	// the global --context can be repeated for the inspections of several clusters
+	contexts := cmdutil.GetFlagStringArray(cmd, "context")
+	if len(contexts) > 1 {
+		return fmt.Errorf("%s supports a single --context, got %d", cmd.Name(), len(contexts))
+	}
+	clientConfigOptions = kubecontexts.ConfigFlags(clientConfigOptions, contexts)[0]
+
+	clientConfig, err := clientConfigOptions.ToRawKubeConfigLoader().ClientConfig()
+	if err != nil {
+		return err
+	}
+	if o.kubeClient, err = kubernetes.NewForConfig(clientConfig); err != nil {
+		return err
+	}
+
+	psaConfig, err := config.LoadPodSecurityConfig(cmd)
+	if err != nil {
+		return err
+	}
+	versionValue := cmdutil.GetFlagString(cmd, "policy-version")
+	policyVersion, fromServer, err := admission.ParsePolicyVersion(versionValue, o.kubeClient.Discovery(), cmdutil.GetFlagInt(cmd, "max-retries"))
+	if err != nil {
+		return fmt.Errorf("failed to resolve --policy-version=%s: %w", versionValue, err)
+	}
+	if fromServer {
+		klog.InfoS("Evaluating against the PodSecurity policy version of the server", "version", policyVersion)
+	}
+	// only the enforce level is reported
+	o.checkOptions.PolicyVersions = admission.PolicyVersions{Enforce: policyVersion, Warn: policyVersion, Audit: policyVersion}
+	o.checkOptions.MaxConcurrency = cmdutil.GetFlagInt(cmd, "max-concurrency")
+	o.checkOptions.Exemptions = psadmissionapi.PodSecurityExemptions{
+		Namespaces:     cmdutil.GetFlagStringSlice(cmd, "exempt-namespace"),
+		RuntimeClasses: cmdutil.GetFlagStringSlice(cmd, "exempt-runtime-class"),
+		Usernames:      cmdutil.GetFlagStringSlice(cmd, "exempt-user"),
+	}
+	if psaConfig != nil {
+		o.checkOptions.Exemptions = admission.MergeExemptions(o.checkOptions.Exemptions, psaConfig.Exemptions)
+	}
+	o.checkOptions.IgnoredChecks = cmdutil.GetFlagStringSlice(cmd, "ignore-control")
+	return nil
+}
+
+func (o *ServeOptions) Validate() []error {
+	var errs []error
+
+	if len(o.certFile) == 0 || len(o.keyFile) == 0 {
+		errs = append(errs, fmt.Errorf("both --tls-cert-file and --tls-private-key-file are required"))
+	}
+
+	return errs
+}
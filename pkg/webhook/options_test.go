@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+- name: prod
+  cluster:
+    server: https://prod.example.com
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: admin
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+current-context: dev
+`
+
+// newTestCommand returns the serve-webhook command with the global flags it
+// reads and the client config flags of the test kubeconfig
+func newTestCommand(t *testing.T, o *ServeOptions, args ...string) (*cobra.Command, *genericclioptions.ConfigFlags) {
+	t.Helper()
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0600); err != nil {
+		t.Fatalf("failed to write the kubeconfig: %v", err)
+	}
+	clientConfigOptions := genericclioptions.NewConfigFlags(true)
+	clientConfigOptions.KubeConfig = &kubeconfig
+	clientConfigOptions.Context = nil
+
+	cmd := &cobra.Command{Use: "serve-webhook"}
+	o.AddFlags(cmd)
+	flags := cmd.Flags()
+	flags.StringArray("context", nil, "")
+	flags.String("policy-version", "latest", "")
+	flags.String("psa-config", "", "")
+	flags.Int("max-retries", 0, "")
+	flags.Int("max-concurrency", 1, "")
+	flags.StringSlice("exempt-namespace", nil, "")
+	flags.StringSlice("exempt-runtime-class", nil, "")
+	flags.StringSlice("exempt-user", nil, "")
+	flags.StringSlice("ignore-control", nil, "")
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("failed to parse the flags: %v", err)
+	}
+	return cmd, clientConfigOptions
+}
+
+func TestCompleteContext(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		expectedHost string
+		expectErr    bool
+	}{
+		{
+			name:         "current context",
+			expectedHost: "dev.example.com",
+		},
+		{
+			name:         "selected context",
+			args:         []string{"--context=prod"},
+			expectedHost: "prod.example.com",
+		},
+		{
+			name:      "several contexts",
+			args:      []string{"--context=dev", "--context=prod"},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newServeOptions()
+			cmd, clientConfigOptions := newTestCommand(t, o, tt.args...)
+
+			err := o.Complete(cmd, clientConfigOptions)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error for %v", tt.args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to complete the options: %v", err)
+			}
+			if host := o.kubeClient.CoreV1().RESTClient().Get().URL().Host; host != tt.expectedHost {
+				t.Errorf("expected the client of %q, got %q", tt.expectedHost, host)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/checker"
+)
+
+// maxRequestBytes is the size limit of the admission reviews, matching the
+// limit of the kube-apiserver request bodies
+const maxRequestBytes = 3 * 1024 * 1024
+
+// RequiredLevelAnnotation is the audit annotation with the computed level of
+// the object, the kube-apiserver prefixes it with the name of the webhook
+const RequiredLevelAnnotation = "required-level"
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(appsv1.AddToScheme(scheme))
+	utilruntime.Must(batchv1.AddToScheme(scheme))
+	utilruntime.Must(admissionv1.AddToScheme(scheme))
+}
+
+// server is a validating webhook that never denies a request but warns about
+// the objects that need a more privileged level than restricted
+type server struct {
+	checker *checker.Checker
+	decoder runtime.Decoder
+}
+
+func newServer(c *checker.Checker) *server {
+	return &server{
+		checker: c,
+		decoder: serializer.NewCodecFactory(scheme).UniversalDeserializer(),
+	}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read the request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	review := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "expected an admission.k8s.io/v1 AdmissionReview request", http.StatusBadRequest)
+		return
+	}
+
+	response := s.review(r, review.Request)
+	response.UID = review.Request.UID
+	review.Request = nil
+	review.Response = response
+	review.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("AdmissionReview"))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		klog.ErrorS(err, "Failed to write the admission response")
+	}
+}
+
+func (s *server) review(r *http.Request, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := &admissionv1.AdmissionResponse{Allowed: true}
+	if len(req.SubResource) > 0 || (req.Operation != admissionv1.Create && req.Operation != admissionv1.Update) {
+		return response
+	}
+
+	obj, gvk, err := s.decoder.Decode(req.Object.Raw, nil, nil)
+	if err != nil {
+		// not one of the workload types, there is nothing to evaluate
+		klog.V(4).InfoS("Skipping object that cannot be decoded", "resource", req.Resource, "namespace", req.Namespace, "name", req.Name, "err", err)
+		return response
+	}
+	obj.GetObjectKind().SetGroupVersionKind(*gvk)
+
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return response
+	}
+	if len(objMeta.GetNamespace()) == 0 {
+		objMeta.SetNamespace(req.Namespace)
+	}
+
+	results, err := s.checker.Check(r.Context(), []runtime.Object{obj})
+	if err != nil {
+		klog.ErrorS(err, "Failed to evaluate object", "kind", gvk.Kind, "namespace", objMeta.GetNamespace(), "name", objMeta.GetName())
+		return response
+	}
+
+	for _, result := range results {
+		level := result.MostRestrictivePolicy()
+		klog.V(4).InfoS("Evaluated object", "kind", gvk.Kind, "namespace", objMeta.GetNamespace(), "name", objMeta.GetName(), "level", level)
+
+		response.AuditAnnotations = map[string]string{RequiredLevelAnnotation: level.String()}
+		if level != admission.LevelRestrictedValue {
+			response.Warnings = append(response.Warnings,
+				fmt.Sprintf("psachecker: %s %q requires the %q PodSecurity level", gvk.Kind, objMeta.GetName(), level),
+			)
+		}
+	}
+	return response
+}
+
+var _ http.Handler = &server{}
@@ -0,0 +1,158 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	psapi "k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/checker"
+)
+
+func newPodSpec(securityContext *corev1.SecurityContext) corev1.PodSpec {
+	return corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "app", Image: "busybox", SecurityContext: securityContext}},
+	}
+}
+
+func newDeployment(securityContext *corev1.SecurityContext) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{Spec: newPodSpec(securityContext)},
+		},
+	}
+}
+
+// postReview sends the object in an AdmissionReview to the server and
+// returns the response of the review
+func postReview(t *testing.T, url, resource string, obj runtime.Object) *admissionv1.AdmissionResponse {
+	t.Helper()
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("failed to encode the object: %v", err)
+	}
+	review := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       types.UID("1234"),
+			Resource:  metav1.GroupVersionResource{Resource: resource},
+			Namespace: "apps",
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("failed to encode the review: %v", err)
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to send the review: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	reviewed := &admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(resp.Body).Decode(reviewed); err != nil {
+		t.Fatalf("failed to decode the response: %v", err)
+	}
+	if reviewed.Response == nil {
+		t.Fatalf("missing the response of the review")
+	}
+	if reviewed.Response.UID != review.Request.UID {
+		t.Errorf("expected the response UID %q, got %q", review.Request.UID, reviewed.Response.UID)
+	}
+	return reviewed.Response
+}
+
+func TestServerReview(t *testing.T) {
+	latest := psapi.LatestVersion()
+	podSecurityChecker, err := checker.NewChecker(fake.NewSimpleClientset(), &checker.Options{
+		ParallelAdmissionOptions: admission.ParallelAdmissionOptions{
+			PolicyVersions: admission.PolicyVersions{Enforce: latest, Warn: latest, Audit: latest},
+			MaxConcurrency: 1,
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to set up the checker: %v", err)
+	}
+	server := httptest.NewServer(newServer(podSecurityChecker))
+	defer server.Close()
+
+	restricted := &corev1.SecurityContext{
+		RunAsNonRoot:             pointer.Bool(true),
+		AllowPrivilegeEscalation: pointer.Bool(false),
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+	tests := []struct {
+		name             string
+		resource         string
+		obj              runtime.Object
+		expectedLevel    string
+		expectedWarnings []string
+	}{
+		{
+			name:     "baseline pod",
+			resource: "pods",
+			obj: &corev1.Pod{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+				ObjectMeta: metav1.ObjectMeta{Name: "toolbox"},
+				Spec:       newPodSpec(nil),
+			},
+			expectedLevel:    "baseline",
+			expectedWarnings: []string{`psachecker: Pod "toolbox" requires the "baseline" PodSecurity level`},
+		},
+		{
+			name:             "privileged deployment",
+			resource:         "deployments",
+			obj:              newDeployment(&corev1.SecurityContext{Privileged: pointer.Bool(true)}),
+			expectedLevel:    "privileged",
+			expectedWarnings: []string{`psachecker: Deployment "web" requires the "privileged" PodSecurity level`},
+		},
+		{
+			name:          "restricted deployment",
+			resource:      "deployments",
+			obj:           newDeployment(restricted),
+			expectedLevel: "restricted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := postReview(t, server.URL, tt.resource, tt.obj)
+
+			if !response.Allowed {
+				t.Errorf("expected the webhook to allow the object, got %v", response.Result)
+			}
+			if level := response.AuditAnnotations[RequiredLevelAnnotation]; level != tt.expectedLevel {
+				t.Errorf("expected the %s annotation %q, got %q", RequiredLevelAnnotation, tt.expectedLevel, level)
+			}
+			if len(response.Warnings) != len(tt.expectedWarnings) {
+				t.Fatalf("expected the warnings %v, got %v", tt.expectedWarnings, response.Warnings)
+			}
+			for i := range tt.expectedWarnings {
+				if response.Warnings[i] != tt.expectedWarnings[i] {
+					t.Errorf("expected the warning %q, got %q", tt.expectedWarnings[i], response.Warnings[i])
+				}
+			}
+		})
+	}
+}
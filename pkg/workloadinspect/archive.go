@@ -0,0 +1,100 @@
+package workloadinspect
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// archiveEntry is a manifest file read from an archive
+type archiveEntry struct {
+	// name is the path of the entry within the archive
+	name string
+	data []byte
+}
+
+// readArchive reads the YAML and JSON files of a tar, gzipped tar or zip
+// archive in memory, the other entries are skipped
+func readArchive(path string) ([]archiveEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the %q archive: %w", path, err)
+	}
+
+	var entries []archiveEntry
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		entries, err = readZip(data)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(bytes.NewReader(data)); err == nil {
+			entries, err = readTar(gz)
+		}
+	default:
+		entries, err = readTar(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the %q archive: %w", path, err)
+	}
+	return entries, nil
+}
+
+func readTar(r io.Reader) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if !hdr.FileInfo().Mode().IsRegular() || !isManifestFile(hdr.Name) {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+		entries = append(entries, archiveEntry{name: hdr.Name, data: data})
+	}
+}
+
+func readZip(data []byte) ([]archiveEntry, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []archiveEntry
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() || !isManifestFile(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		entries = append(entries, archiveEntry{name: f.Name, data: data})
+	}
+	return entries, nil
+}
+
+// isManifestFile matches the extensions the resource builder reads from directories
+func isManifestFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
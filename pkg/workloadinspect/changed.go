@@ -0,0 +1,109 @@
+package workloadinspect
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// changedFiles returns the absolute paths of the files of the git repository
+// of the working directory that were changed since the ref, the untracked
+// files included. The returned bool is false if the working directory is not
+// in a git repository.
+func changedFiles(ref string) (map[string]bool, bool, error) {
+	topLevel, err := runGit("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, false, nil
+	}
+	topLevel = strings.TrimSpace(topLevel)
+
+	// the deleted files have no manifests left to inspect
+	diff, err := runGit("diff", "--name-only", "--diff-filter=d", ref, "--")
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to list the files changed since %q: %w", ref, err)
+	}
+	untracked, err := runGit("ls-files", "--others", "--exclude-standard", "--full-name", ":/")
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to list the untracked files: %w", err)
+	}
+
+	changed := map[string]bool{}
+	for _, name := range strings.Split(diff+untracked, "\n") {
+		if len(name) == 0 {
+			continue
+		}
+		changed[filepath.Join(topLevel, filepath.FromSlash(name))] = true
+	}
+	return changed, true, nil
+}
+
+func runGit(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	gitCmd := exec.Command("git", args...)
+	gitCmd.Stdout = &stdout
+	gitCmd.Stderr = &stderr
+	if err := gitCmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); len(msg) > 0 {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// withUnchangedFilesRemoved returns a copy of the filename options with only
+// the changed files, the directories are replaced by the changed manifests in
+// them. The URLs are kept as they are.
+func withUnchangedFilesRemoved(filenameOptions *resource.FilenameOptions, changed map[string]bool) (*resource.FilenameOptions, error) {
+	ret := *filenameOptions
+	ret.Filenames = make([]string, 0, len(filenameOptions.Filenames))
+
+	for _, f := range filenameOptions.Filenames {
+		if strings.HasPrefix(f, "http://") || strings.HasPrefix(f, "https://") {
+			ret.Filenames = append(ret.Filenames, f)
+			continue
+		}
+		absPath, err := filepath.Abs(f)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(f)
+		if err != nil {
+			// let the builder report the errors
+			ret.Filenames = append(ret.Filenames, f)
+			continue
+		}
+		if !info.IsDir() {
+			if changed[absPath] {
+				ret.Filenames = append(ret.Filenames, f)
+			}
+			continue
+		}
+
+		var dirFiles []string
+		for path := range changed {
+			relPath, err := filepath.Rel(absPath, path)
+			if err != nil || strings.HasPrefix(relPath, "..") {
+				continue
+			}
+			if !filenameOptions.Recursive && strings.ContainsRune(relPath, filepath.Separator) {
+				continue
+			}
+			for _, ext := range manifestExtensions {
+				if filepath.Ext(path) == ext {
+					dirFiles = append(dirFiles, filepath.Join(f, relPath))
+					break
+				}
+			}
+		}
+		sort.Strings(dirFiles)
+		ret.Filenames = append(ret.Filenames, dirFiles...)
+	}
+	return &ret, nil
+}
@@ -7,31 +7,96 @@ import (
 	"github.com/spf13/cobra"
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/config"
+	"github.com/stlaz/psachecker/pkg/kubecontexts"
+	"github.com/stlaz/psachecker/pkg/nslabels"
+	"github.com/stlaz/psachecker/pkg/printers"
 )
 
 func NewWorkloadInspectCommand(clientConfigOptions *genericclioptions.ConfigFlags) *cobra.Command {
 	o := newWorkloadInspectOptions()
 
 	cmd := &cobra.Command{
-		Use:          "inspect-workloads [flags]",
+		Use:          "inspect-workloads [TYPE[,TYPE...] [NAME...]] [flags]",
 		Short:        "get the least privileged PodSecurity level for your workload to keep current workloads running successfully",
 		SilenceUsage: true,
 		RunE: func(c *cobra.Command, args []string) error {
-			o.Complete(c, args, clientConfigOptions)
-			errs := o.Validate()
-			if len(errs) > 0 {
-				return fmt.Errorf("there were errors while setting up the command: %v", errs)
+			if err := config.LoadFlagDefaults(c); err != nil {
+				return err
 			}
 
-			nsAggregatedResults, err := o.Run(context.Background())
+			contexts := cmdutil.GetFlagStringArray(c, "context")
+			if len(contexts) > 1 && cmdutil.GetFlagBool(c, "generate-labels") {
+				return fmt.Errorf("--generate-labels cannot be used with multiple contexts")
+			}
+
+			requestTimeout, err := clientcmd.ParseTimeout(*clientConfigOptions.Timeout)
 			if err != nil {
 				return err
 			}
+			ctx := context.Background()
+			if requestTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, requestTimeout)
+				defer cancel()
+			}
 
-			for _, ns := range nsAggregatedResults.Keys() {
-				fmt.Fprintf(c.OutOrStdout(), "%s: %s\n", ns, nsAggregatedResults.Get(ns))
+			var contextResults []*kubecontexts.Result
+			for _, contextFlags := range kubecontexts.ConfigFlags(clientConfigOptions, contexts) {
+				if err := o.Complete(c, args, contextFlags); err != nil {
+					return err
+				}
+				errs := o.Validate()
+				if len(errs) > 0 {
+					return fmt.Errorf("there were errors while setting up the command: %v", errs)
+				}
+				nsAggregatedResults, err := o.Run(ctx)
+				if err != nil {
+					return err
+				}
+
+				contextResult := &kubecontexts.Result{KubeClient: o.kubeClient, Results: nsAggregatedResults}
+				if contextFlags.Context != nil {
+					contextResult.Context = *contextFlags.Context
+				}
+				contextResults = append(contextResults, contextResult)
+			}
+			nsAggregatedResults := kubecontexts.MergeResults(contextResults)
+
+			if err := printers.WriteResults(c.OutOrStdout(), nsAggregatedResults, o.printOptions); err != nil {
+				return err
+			}
+			o.printParseErrors(c.ErrOrStderr())
+			if o.printOptions.ExitSummary {
+				printers.PrintExitSummary(c.ErrOrStderr(), nsAggregatedResults, o.maxLevel)
+			}
+
+			if o.applyOptions != nil {
+				for _, contextResult := range contextResults {
+					if err := nslabels.ApplyLabels(ctx, c.OutOrStdout(), contextResult.KubeClient, contextResult.Results, o.applyOptions); err != nil {
+						return err
+					}
+				}
+			}
+
+			if len(o.maxLevel) > 0 {
+				if err := admission.CheckMaxLevel(nsAggregatedResults, o.maxLevel); err != nil {
+					return err
+				}
+			}
+
+			if o.strict {
+				if denied := admission.DeniedObjects(nsAggregatedResults, o.targetLevel); len(denied) > 0 {
+					printers.PrintDenials(c.ErrOrStderr(), denied, o.targetLevel)
+					return &admission.PolicyError{Err: fmt.Errorf("%d objects would be denied at the %q level", len(denied), o.targetLevel)}
+				}
 			}
 			return nil
+
 		},
 	}
 
@@ -0,0 +1,44 @@
+package workloadinspect
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// deprecatedAPI is a deprecated or removed group version of a workload kind
+// whose objects can still be evaluated as objects of the replacement
+type deprecatedAPI struct {
+	replacement schema.GroupVersion
+	// deprecatedIn is empty for the alpha versions, those are removed
+	// without a deprecation period
+	deprecatedIn, removedIn string
+}
+
+var deprecatedAPIs = map[schema.GroupVersionKind]deprecatedAPI{}
+
+func init() {
+	removedIn116 := deprecatedAPI{replacement: appsv1.SchemeGroupVersion, deprecatedIn: "v1.9", removedIn: "v1.16"}
+	for gv, kinds := range map[schema.GroupVersion][]string{
+		{Group: "extensions", Version: "v1beta1"}: {"Deployment", "DaemonSet", "ReplicaSet"},
+		{Group: "apps", Version: "v1beta1"}:       {"Deployment", "StatefulSet"},
+		{Group: "apps", Version: "v1beta2"}:       {"Deployment", "StatefulSet", "DaemonSet", "ReplicaSet"},
+	} {
+		for _, kind := range kinds {
+			deprecatedAPIs[gv.WithKind(kind)] = removedIn116
+		}
+	}
+	deprecatedAPIs[schema.GroupVersionKind{Group: "batch", Version: "v1beta1", Kind: "CronJob"}] =
+		deprecatedAPI{replacement: batchv1.SchemeGroupVersion, deprecatedIn: "v1.21", removedIn: "v1.25"}
+	deprecatedAPIs[schema.GroupVersionKind{Group: "batch", Version: "v2alpha1", Kind: "CronJob"}] =
+		deprecatedAPI{replacement: batchv1.SchemeGroupVersion, removedIn: "v1.21"}
+}
+
+func (d deprecatedAPI) warning(gvk schema.GroupVersionKind) string {
+	if len(d.deprecatedIn) == 0 {
+		return fmt.Sprintf("%s %s was removed in %s, use %s", gvk.GroupVersion(), gvk.Kind, d.removedIn, d.replacement)
+	}
+	return fmt.Sprintf("%s %s is deprecated since %s and removed in %s, use %s", gvk.GroupVersion(), gvk.Kind, d.deprecatedIn, d.removedIn, d.replacement)
+}
@@ -0,0 +1,56 @@
+package workloadinspect
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// typedInfos converts the unstructured objects decoded from the documents of
+// local files to the types of the scheme. The documents of kinds missing in
+// the scheme or that fail to convert are left out and reported by their kind,
+// name and source all at once. The objects of deprecated API versions are
+// converted to their replacements, the returned map holds the warnings about them.
+func typedInfos(infos []*resource.Info) ([]*resource.Info, map[runtime.Object]string, []error) {
+	var invalid []error
+	typedInfos := make([]*resource.Info, 0, len(infos))
+	apiWarnings := map[runtime.Object]string{}
+	for _, info := range infos {
+		u, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			typedInfos = append(typedInfos, info)
+			continue
+		}
+
+		gvk := u.GroupVersionKind()
+		var apiWarning string
+		if deprecated, ok := deprecatedAPIs[gvk]; ok {
+			// the pod templates did not change between the versions
+			apiWarning = deprecated.warning(gvk)
+			gvk = deprecated.replacement.WithKind(gvk.Kind)
+			u.SetAPIVersion(gvk.GroupVersion().String())
+		}
+		if !scheme.Recognizes(gvk) {
+			invalid = append(invalid, fmt.Errorf("unsupported kind of object %s/%s (%s) in %s", gvk.Kind, u.GetName(), gvk.GroupVersion(), info.Source))
+			continue
+		}
+
+		typed, err := scheme.New(gvk)
+		if err == nil {
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), typed)
+		}
+		if err != nil {
+			invalid = append(invalid, fmt.Errorf("failed to decode %s/%s in %s: %w", gvk.Kind, u.GetName(), info.Source, err))
+			continue
+		}
+		typed.GetObjectKind().SetGroupVersionKind(gvk)
+		info.Object = typed
+		typedInfos = append(typedInfos, info)
+		if len(apiWarning) > 0 {
+			apiWarnings[typed] = apiWarning
+		}
+	}
+	return typedInfos, apiWarnings, invalid
+}
@@ -0,0 +1,249 @@
+package workloadinspect
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// inspectExamples evaluates the manifests of the examples directory the same
+// way as `inspect-workloads --offline -f` does
+func inspectExamples(t *testing.T, names ...string) *admission.OrderedNamespaceResultsMap {
+	t.Helper()
+
+	filenames := make([]string, 0, len(names))
+	for _, name := range names {
+		filenames = append(filenames, filepath.Join("..", "..", "examples", name))
+	}
+
+	clientConfigOptions := genericclioptions.NewConfigFlags(false)
+	latest := psapi.LatestVersion()
+	opts := newWorkloadInspectOptions()
+	opts.clientConfigOptions = clientConfigOptions
+	opts.admissionOptions.PolicyVersions = admission.PolicyVersions{Enforce: latest, Warn: latest, Audit: latest}
+	opts.admissionOptions.MaxConcurrency = 1
+	opts.errOut = io.Discard
+	opts.isLocal = true
+	opts.offline = true
+	opts.builder = resource.NewBuilder(clientConfigOptions).
+		Unstructured().
+		Local().
+		FilenameParam(false, &resource.FilenameOptions{Filenames: filenames}).
+		Flatten().
+		ContinueOnError()
+
+	results, err := opts.Run(context.Background())
+	if err != nil {
+		t.Fatalf("failed to inspect %v: %v", names, err)
+	}
+	if len(opts.parseErrors) > 0 {
+		t.Fatalf("failed to parse %v: %v", names, opts.parseErrors)
+	}
+	return results
+}
+
+// exampleObject returns the result of the object of the kind and name in the
+// namespace, it fails the test if there is none
+func exampleObject(t *testing.T, results *admission.OrderedNamespaceResultsMap, namespace, kind, name string) *admission.ObjectResult {
+	t.Helper()
+
+	nsResult := results.Get(namespace)
+	if nsResult == nil {
+		t.Fatalf("missing the results of namespace %q, got %v", namespace, results.Keys())
+	}
+	for _, obj := range nsResult.Objects {
+		if obj.Kind == kind && obj.Name == name {
+			return obj
+		}
+	}
+	t.Fatalf("missing the result of %s/%s in namespace %q", kind, name, namespace)
+	return nil
+}
+
+func failedCheckIDs(obj *admission.ObjectResult) []string {
+	ids := make([]string, 0, len(obj.Result.FailedChecks))
+	for _, check := range obj.Result.FailedChecks {
+		ids = append(ids, check.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func hasFailedCheck(obj *admission.ObjectResult, id string) bool {
+	for _, check := range obj.Result.FailedChecks {
+		if check.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStatefulSetAndDaemonSetExamples(t *testing.T) {
+	results := inspectExamples(t, "statefulset-hostnetwork.yaml", "daemonset-hostpath.yaml")
+
+	for _, tc := range []struct {
+		namespace, kind, name string
+		failedChecks          []string
+	}{
+		{namespace: "data", kind: "StatefulSet", name: "db", failedChecks: []string{"hostNamespaces"}},
+		{namespace: "monitoring", kind: "DaemonSet", name: "agent", failedChecks: []string{"hostNamespaces", "hostPathVolumes"}},
+	} {
+		t.Run(tc.kind, func(t *testing.T) {
+			obj := exampleObject(t, results, tc.namespace, tc.kind, tc.name)
+			if level := obj.Result.Level(); level != admission.LevelPrivilegedValue {
+				t.Errorf("expected %s/%s to fail baseline and require privileged, got %s", tc.kind, tc.name, level)
+			}
+			for _, id := range tc.failedChecks {
+				if !hasFailedCheck(obj, id) {
+					t.Errorf("expected %s/%s to fail the %s check, got %v", tc.kind, tc.name, id, failedCheckIDs(obj))
+				}
+			}
+			if level := results.Get(tc.namespace).Level; level != admission.LevelPrivilegedValue {
+				t.Errorf("expected namespace %q to require privileged, got %s", tc.namespace, level)
+			}
+		})
+	}
+}
+
+func TestReplicaSetAndReplicationControllerExamples(t *testing.T) {
+	results := inspectExamples(t, "replicaset-hostnetwork.yaml", "replicationcontroller-hostpid.yaml")
+
+	for _, tc := range []struct {
+		kind, name string
+	}{
+		{kind: "ReplicaSet", name: "legacy-web"},
+		{kind: "ReplicationController", name: "legacy-agent"},
+	} {
+		t.Run(tc.kind, func(t *testing.T) {
+			obj := exampleObject(t, results, "legacy", tc.kind, tc.name)
+			if level := obj.Result.Level(); level != admission.LevelPrivilegedValue {
+				t.Errorf("expected %s/%s to fail baseline and require privileged, got %s", tc.kind, tc.name, level)
+			}
+			if !hasFailedCheck(obj, "hostNamespaces") {
+				t.Errorf("expected %s/%s to fail the hostNamespaces check, got %v", tc.kind, tc.name, failedCheckIDs(obj))
+			}
+		})
+	}
+}
+
+func TestMultiDocumentExample(t *testing.T) {
+	results := inspectExamples(t, "multi-document.yaml")
+
+	for _, tc := range []struct {
+		kind, name string
+		level      admission.Level
+	}{
+		{kind: "Deployment", name: "shop-web", level: admission.LevelBaselineValue},
+		{kind: "CronJob", name: "shop-backup", level: admission.LevelPrivilegedValue},
+		{kind: "Pod", name: "shop-debug", level: admission.LevelRestrictedValue},
+	} {
+		t.Run(tc.kind, func(t *testing.T) {
+			obj := exampleObject(t, results, "shop", tc.kind, tc.name)
+			if level := obj.Result.Level(); level != tc.level {
+				t.Errorf("expected %s/%s to require %s, got %s", tc.kind, tc.name, tc.level, level)
+			}
+		})
+	}
+	if objects := results.Get("shop").Objects; len(objects) != 3 {
+		t.Errorf("expected the 3 documents to be evaluated, got %d objects", len(objects))
+	}
+}
+
+func TestCronJobJobsExample(t *testing.T) {
+	results := inspectExamples(t, "cronjob-jobs.yaml", "job-seccomp.yaml")
+
+	objects := results.Get("pipelines").Objects
+	if len(objects) != 2 {
+		t.Fatalf("expected the CronJob and the Job that it does not own to be evaluated, got %d objects", len(objects))
+	}
+
+	cronJob := exampleObject(t, results, "pipelines", "CronJob", "report")
+	if expected := []string{"Job/report-27700020"}; !reflect.DeepEqual(cronJob.ResolvedFrom, expected) {
+		t.Errorf("expected the CronJob to be resolved from %v, got %v", expected, cronJob.ResolvedFrom)
+	}
+	if level := cronJob.Result.Level(); level != admission.LevelRestrictedValue {
+		t.Errorf("expected the CronJob to meet restricted, got %s", level)
+	}
+
+	job := exampleObject(t, results, "pipelines", "Job", "migrate")
+	if level := job.Result.Level(); level != admission.LevelBaselineValue {
+		t.Errorf("expected the Job to require baseline, got %s", level)
+	}
+	if !hasFailedCheck(job, "seccompProfile_restricted") {
+		t.Errorf("expected the Job to fail the seccompProfile_restricted check, got %v", failedCheckIDs(job))
+	}
+}
+
+func TestNoContainersExample(t *testing.T) {
+	results := inspectExamples(t, "deployment-no-containers.yaml")
+
+	broken := exampleObject(t, results, "batch-jobs", "Deployment", "broken")
+	if !broken.Result.NoContainers {
+		t.Errorf("expected the Deployment without containers to be noted as such")
+	}
+	if level := broken.Result.Level(); level != admission.LevelRestrictedValue {
+		t.Errorf("expected the Deployment without containers to fail none of the levels, got %s", level)
+	}
+	if len(broken.Result.FailedChecks) > 0 {
+		t.Errorf("expected the Deployment without containers to fail no checks, got %v", failedCheckIDs(broken))
+	}
+
+	initOnly := exampleObject(t, results, "batch-jobs", "Deployment", "init-only")
+	if initOnly.Result.NoContainers {
+		t.Errorf("expected the Deployment with only init containers to be evaluated")
+	}
+	if level := initOnly.Result.Level(); level != admission.LevelBaselineValue {
+		t.Errorf("expected the Deployment with only init containers to require baseline, got %s", level)
+	}
+	for _, check := range initOnly.Result.FailedChecks {
+		if !reflect.DeepEqual(check.Containers, []string{"init"}) {
+			t.Errorf("expected the %s check to fail on the init container, got %v", check.ID, check.Containers)
+		}
+	}
+}
+
+func TestListExample(t *testing.T) {
+	results := inspectExamples(t, "list.json")
+
+	if objects := results.Get("shop").Objects; len(objects) != 2 {
+		t.Fatalf("expected the 2 items of the List to be evaluated, got %d objects", len(objects))
+	}
+	for _, tc := range []struct {
+		kind, name string
+		level      admission.Level
+	}{
+		{kind: "Deployment", name: "web", level: admission.LevelPrivilegedValue},
+		{kind: "Pod", name: "debug", level: admission.LevelBaselineValue},
+	} {
+		t.Run(tc.kind, func(t *testing.T) {
+			obj := exampleObject(t, results, "shop", tc.kind, tc.name)
+			if level := obj.Result.Level(); level != tc.level {
+				t.Errorf("expected %s/%s to require %s, got %s", tc.kind, tc.name, tc.level, level)
+			}
+		})
+	}
+}
+
+func TestPodTemplateExample(t *testing.T) {
+	results := inspectExamples(t, "podtemplate-hostipc.yaml")
+
+	obj := exampleObject(t, results, "gitops", "PodTemplate", "shm-worker")
+	if obj.Result.NoContainers {
+		t.Errorf("expected the template of the PodTemplate to be extracted")
+	}
+	if level := obj.Result.Level(); level != admission.LevelPrivilegedValue {
+		t.Errorf("expected the PodTemplate to fail baseline and require privileged, got %s", level)
+	}
+	if !hasFailedCheck(obj, "hostNamespaces") {
+		t.Errorf("expected the PodTemplate to fail the hostNamespaces check, got %v", failedCheckIDs(obj))
+	}
+}
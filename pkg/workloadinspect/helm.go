@@ -0,0 +1,32 @@
+package workloadinspect
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// renderHelmChart renders the manifests of the chart with `helm template`
+// using the given values files
+func renderHelmChart(chart string, valuesFiles []string, namespace string) ([]byte, error) {
+	args := []string{"template", chart}
+	for _, values := range valuesFiles {
+		args = append(args, "--values", values)
+	}
+	if len(namespace) > 0 {
+		args = append(args, "--namespace", namespace)
+	}
+
+	var stdout, stderr bytes.Buffer
+	helmCmd := exec.Command("helm", args...)
+	helmCmd.Stdout = &stdout
+	helmCmd.Stderr = &stderr
+	if err := helmCmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); len(msg) > 0 {
+			return nil, fmt.Errorf("failed to render the %q helm chart: %w: %s", chart, err, msg)
+		}
+		return nil, fmt.Errorf("failed to render the %q helm chart: %w", chart, err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,177 @@
+package workloadinspect
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// ignoreFileName is the file listing the gitignore-style patterns of the paths
+// that should not be inspected within the directory it is placed in
+const ignoreFileName = ".psacheckerignore"
+
+// manifestExtensions are the extensions of the files the resource builder
+// picks up when it walks a directory by itself
+var manifestExtensions = []string{".json", ".yaml", ".yml"}
+
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+type ignoreRules []*ignoreRule
+
+// readIgnoreFile returns the rules of the ignore file in dir, nil if there is none
+func readIgnoreFile(dir string) (ignoreRules, error) {
+	f, err := os.Open(filepath.Join(dir, ignoreFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rules := ignoreRules{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := &ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		// patterns with a slash are relative to the directory, the rest match at any depth
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+
+		expr := globToRegexp(line)
+		if !anchored {
+			expr = "(.*/)?" + expr
+		}
+		if rule.pattern, err = regexp.Compile("^" + expr + "$"); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q in %s: %w", scanner.Text(), filepath.Join(dir, ignoreFileName), err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			if end := strings.IndexByte(glob[i:], ']'); end > 0 {
+				expr.WriteString(glob[i : i+end+1])
+				i += end
+				continue
+			}
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String()
+}
+
+// ignored returns whether the slash-separated path relative to the directory
+// of the ignore file matches the rules, the last matching rule wins
+func (r ignoreRules) ignored(relPath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range r {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.pattern.MatchString(relPath) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// withIgnoredFilesRemoved replaces the directories that contain an ignore file
+// with the manifests in them that are not ignored
+func withIgnoredFilesRemoved(filenameOptions *resource.FilenameOptions) (*resource.FilenameOptions, error) {
+	ret := *filenameOptions
+	ret.Filenames = make([]string, 0, len(filenameOptions.Filenames))
+
+	for _, f := range filenameOptions.Filenames {
+		info, err := os.Stat(f)
+		if err != nil || !info.IsDir() {
+			// let the builder deal with URLs and report the errors
+			ret.Filenames = append(ret.Filenames, f)
+			continue
+		}
+
+		rules, err := readIgnoreFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if rules == nil {
+			ret.Filenames = append(ret.Filenames, f)
+			continue
+		}
+
+		err = filepath.Walk(f, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if path == f {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(f, path)
+			if err != nil {
+				return err
+			}
+			if rules.ignored(filepath.ToSlash(relPath), info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if info.IsDir() {
+				if !filenameOptions.Recursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			for _, ext := range manifestExtensions {
+				if filepath.Ext(path) == ext {
+					ret.Filenames = append(ret.Filenames, path)
+					break
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &ret, nil
+}
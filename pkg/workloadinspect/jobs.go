@@ -0,0 +1,54 @@
+package workloadinspect
+
+import (
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/klog/v2"
+)
+
+// withoutCronJobJobs drops the Jobs controlled by the CronJobs among the
+// infos, their pod templates come from the job template of the CronJob and
+// would be counted twice otherwise. The dropped Jobs are added to the
+// resolvedFrom map, keyed by the namespace and the "Kind/name" of their CronJobs.
+func withoutCronJobJobs(infos []*resource.Info, resolvedFrom map[string]map[string][]string) ([]*resource.Info, map[string]map[string][]string) {
+	cronJobs := map[string]map[string]bool{}
+	for _, info := range infos {
+		if cronJob, ok := info.Object.(*batchv1.CronJob); ok {
+			if cronJobs[info.Namespace] == nil {
+				cronJobs[info.Namespace] = map[string]bool{}
+			}
+			cronJobs[info.Namespace][cronJob.Name] = true
+		}
+	}
+	if len(cronJobs) == 0 {
+		return infos, resolvedFrom
+	}
+
+	filtered := make([]*resource.Info, 0, len(infos))
+	for _, info := range infos {
+		job, ok := info.Object.(*batchv1.Job)
+		if !ok {
+			filtered = append(filtered, info)
+			continue
+		}
+		ref := metav1.GetControllerOf(job)
+		if ref == nil || ref.Kind != "CronJob" || !cronJobs[info.Namespace][ref.Name] {
+			filtered = append(filtered, info)
+			continue
+		}
+
+		cronJobName := fmt.Sprintf("CronJob/%s", ref.Name)
+		if resolvedFrom == nil {
+			resolvedFrom = map[string]map[string][]string{}
+		}
+		if resolvedFrom[info.Namespace] == nil {
+			resolvedFrom[info.Namespace] = map[string][]string{}
+		}
+		resolvedFrom[info.Namespace][cronJobName] = append(resolvedFrom[info.Namespace][cronJobName], fmt.Sprintf("Job/%s", job.Name))
+		klog.V(4).InfoS("Skipping the job of cronjob", "job", klog.KObj(job), "cronJob", ref.Name)
+	}
+	return filtered, resolvedFrom
+}
@@ -0,0 +1,59 @@
+package workloadinspect
+
+import (
+	"reflect"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/utils/pointer"
+)
+
+func newJobInfo(namespace, name, cronJob string) *resource.Info {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	if len(cronJob) > 0 {
+		job.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: "batch/v1",
+			Kind:       "CronJob",
+			Name:       cronJob,
+			Controller: pointer.Bool(true),
+		}}
+	}
+	return &resource.Info{Object: job, Namespace: namespace, Name: name}
+}
+
+func TestWithoutCronJobJobs(t *testing.T) {
+	cronJob := &resource.Info{
+		Object:    &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Namespace: "pipelines", Name: "report"}},
+		Namespace: "pipelines",
+		Name:      "report",
+	}
+	infos := []*resource.Info{
+		cronJob,
+		newJobInfo("pipelines", "report-1", "report"),
+		newJobInfo("pipelines", "report-2", "report"),
+		// the CronJob of the same name in another namespace is not inspected
+		newJobInfo("batch", "report-3", "report"),
+		// the CronJob is not inspected
+		newJobInfo("pipelines", "backup-1", "backup"),
+		newJobInfo("pipelines", "migrate", ""),
+	}
+
+	filtered, resolvedFrom := withoutCronJobJobs(infos, nil)
+
+	var names []string
+	for _, info := range filtered {
+		names = append(names, info.Namespace+"/"+info.Name)
+	}
+	if expected := []string{"pipelines/report", "batch/report-3", "pipelines/backup-1", "pipelines/migrate"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected the objects %v to be evaluated, got %v", expected, names)
+	}
+
+	expected := map[string]map[string][]string{
+		"pipelines": {"CronJob/report": {"Job/report-1", "Job/report-2"}},
+	}
+	if !reflect.DeepEqual(resolvedFrom, expected) {
+		t.Errorf("expected the jobs to be resolved to %v, got %v", expected, resolvedFrom)
+	}
+}
@@ -0,0 +1,29 @@
+package workloadinspect
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	// podNamespaceEnv is usually set from the downward API of the pod the
+	// checks run in
+	podNamespaceEnv = "POD_NAMESPACE"
+	// serviceAccountNamespaceFile is mounted into the pods along with the
+	// token of their service account
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// inClusterNamespace is the namespace of the pod psachecker runs in, taken
+// from the POD_NAMESPACE environment variable or from the namespace of the
+// service account, empty if it runs outside of a pod
+func inClusterNamespace() string {
+	if ns := strings.TrimSpace(os.Getenv(podNamespaceEnv)); len(ns) > 0 {
+		return ns
+	}
+	data, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -1,27 +1,49 @@
 package workloadinspect
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/apiretry"
+	"github.com/stlaz/psachecker/pkg/checker"
+	"github.com/stlaz/psachecker/pkg/config"
+	"github.com/stlaz/psachecker/pkg/nslabels"
+	"github.com/stlaz/psachecker/pkg/printers"
+	"github.com/stlaz/psachecker/pkg/progress"
 
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	psadmission "k8s.io/pod-security-admission/admission"
+	psadmissionapi "k8s.io/pod-security-admission/admission/api"
 	psapi "k8s.io/pod-security-admission/api"
 )
 
 var (
 	scheme = runtime.NewScheme()
+
+	// these are intentionally privileged in most clusters
+	defaultExcludedNamespaces = []string{"kube-*"}
 )
 
 func init() {
@@ -33,71 +55,355 @@ func init() {
 type WorkloadInspectOptions struct {
 	clientConfigOptions *genericclioptions.ConfigFlags
 	filenameOptions     *resource.FilenameOptions
+	helmChart           string
+	helmValues          []string
+	archives            []string
+	changedSince        string
 
 	updatesOnly       bool
+	compareLabels     bool
 	defaultNamespaces bool
-
-	builder    *resource.Builder
-	kubeClient kubernetes.Interface
+	namespaceOverride string
+	allNamespaces     bool
+	namespaces        []string
+	excludeNamespaces []string
+	fieldSelector     string
+	noDefaultExcludes bool
+	includeSystemPods bool
+	maxLevel          psapi.Level
+	strict            bool
+	offline           bool
+	strictNamespaces  bool
+	securityContext   string
+	resolveOwners     bool
+	checkReplicaSets  bool
+	failOnMissingNS   bool
+	strictParse       bool
+	onlyCheck         string
+	// resolveScaleTarget replaces the HPAs by their scale targets, the cluster
+	// objects are listed as unstructured then
+	resolveScaleTarget bool
+	// nsVersionLabel is the label of the live namespaces whose pinned policy
+	// version the objects are evaluated against instead of the global one
+	nsVersionLabel string
+	// comparedVersions are the policy versions the objects are evaluated
+	// against side by side, only set if several were passed, the first one
+	// is the one of the admission options
+	comparedVersions []psapi.Version
+	maxRetries       int
+	podSpecPatch     []byte
+	targetLevel      psapi.Level
+	// psaDefaults are the levels and versions of the namespaces without
+	// PodSecurity labels in the cluster, only set with --psa-config
+	psaDefaults      *psadmissionapi.PodSecurityDefaults
+	admissionOptions *admission.ParallelAdmissionOptions
+	applyOptions     *nslabels.ApplyOptions
+	printOptions     *printers.PrintOptions
+
+	builder *resource.Builder
+	// namespaceBuilders list the workloads of each of the --namespaces, the
+	// builder is not used then
+	namespaceBuilders []*resource.Builder
+	kubeClient        kubernetes.Interface
 
 	isLocal bool
+	// noChangedFiles is set if none of the files changed since the
+	// --changed-since ref, there is nothing to inspect then
+	noChangedFiles bool
+	// parseErrors are the errors of the documents of local files that were
+	// left out of the last run, see printParseErrors()
+	parseErrors []error
+
+	errOut io.Writer
 }
 
 func newWorkloadInspectOptions() *WorkloadInspectOptions {
 	return &WorkloadInspectOptions{
-		filenameOptions: &resource.FilenameOptions{},
+		filenameOptions:  &resource.FilenameOptions{},
+		admissionOptions: &admission.ParallelAdmissionOptions{},
+		printOptions:     &printers.PrintOptions{},
 	}
 }
 
 func (o *WorkloadInspectOptions) AddFlags(cmd *cobra.Command) {
 	flags := cmd.Flags()
 
-	cmdutil.AddFilenameOptionFlags(cmd, // TODO: this adds a kustomize flag, do we need to special-case handle it?
+	// also adds -k, the builder renders the kustomization as part of the filename options
+	cmdutil.AddFilenameOptionFlags(cmd,
 		o.filenameOptions,
 		"identifying the resource to run PodSecurity admission check against",
 	)
 
-	flags.BoolVar(&o.defaultNamespaces, "default-namespaces", false, "Default empty namespaces in files to the --namespace value.")
+	flags.StringVar(&o.helmChart, "helm-chart", "", "Render the chart with `helm template` and inspect the resulting manifests as local files.")
+	flags.StringArrayVar(&o.helmValues, "values", nil, "Values file to render the --helm-chart with. Can be set multiple times.")
+	flags.StringArrayVar(&o.archives, "archive", nil, "Tar, gzipped tar or zip archive whose YAML and JSON files to inspect as local files, read in memory. Can be set multiple times.")
+	flags.StringVar(&o.changedSince, "changed-since", "", "Only inspect the files, or the manifests in the directories, passed by -f that were changed since this git ref, e.g. \"origin/main\". All of them are inspected outside of a git repository.")
+	flags.BoolVar(&o.defaultNamespaces, "default-namespaces", false, "Default empty namespaces in files to the --namespace value. In a pod, --namespace defaults to the POD_NAMESPACE environment variable or to the namespace of its service account.")
+	flags.StringVar(&o.namespaceOverride, "namespace-override", "", "Evaluate all the objects in files as if they were in this namespace, regardless of the namespace in their definition.")
+	flags.BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "Inspect the workloads in all namespaces of the cluster. All supported workload types are inspected unless a resource type is specified.")
+	flags.StringVar(&o.fieldSelector, "field-selector", "", "Only inspect the objects in the cluster matching the field selector, e.g. \"spec.nodeName=node-1\" or \"status.phase=Running\" for pods. Supports '=', '==' and '!='.")
+	flags.StringSliceVar(&o.namespaces, "namespaces", nil, "Comma-separated list of namespaces to inspect the workloads of, the same way as --all-namespaces inspects all of them. All supported workload types are inspected unless a resource type is specified.")
+	flags.StringArrayVar(&o.excludeNamespaces, "exclude-namespace", nil, "Namespace to skip with --all-namespaces, can be a glob pattern. Can be set multiple times.")
+	flags.BoolVar(&o.noDefaultExcludes, "no-default-excludes", false, fmt.Sprintf("Do not skip the %q namespaces by default with --all-namespaces.", strings.Join(defaultExcludedNamespaces, ", ")))
+	flags.BoolVar(&o.includeSystemPods, "include-system-pods", false, "Also evaluate the mirror pods of the static pods with --all-namespaces, the ones in the namespaces skipped by default included. They are marked as static pods in the results.")
+	flags.BoolVar(&o.strict, "strict", false, "Fail if any of the objects would be denied at the --target-level.")
+	flags.BoolVar(&o.strictNamespaces, "strict-namespaces", false, "Fail if the namespace of an object in files differs from the --namespace value instead of only warning about it.")
+	flags.StringVar(&o.securityContext, "with-security-context", "", `Inline JSON/YAML pod spec fragment merged onto the pod spec of each object before the evaluation, e.g. '{"securityContext": {"runAsNonRoot": true}}'.`)
+	flags.BoolVar(&o.resolveOwners, "resolve-owners", false, "Evaluate the top controllers of the pods in the cluster, e.g. the Deployment of a pod, instead of the pods themselves.")
+	flags.BoolVar(&o.resolveScaleTarget, "resolve-scale-target", false, "Evaluate the workloads scaled by the HorizontalPodAutoscalers in the cluster, e.g. \"hpa/web\", instead of the autoscalers themselves. Their targets of unsupported kinds are skipped with a warning.")
+	flags.BoolVar(&o.checkReplicaSets, "check-replicasets", false, "Also evaluate the ReplicaSets of the Deployments in the cluster, including the ones of the previous rollouts that may still have pods running.")
+	flags.BoolVar(&o.failOnMissingNS, "fail-on-missing-namespace", false, "Fail if the namespace of an object does not exist in the cluster instead of treating it as unlabeled. Makes the namespaces of local files be looked up in the cluster.")
+	flags.BoolVar(&o.strictParse, "strict-parse", false, "Fail on the first document of the local files that cannot be parsed or is of an unsupported kind instead of leaving it out and listing it after the results.")
+	flags.BoolVar(&o.offline, "offline", false, "Evaluate local files without connecting to the cluster, no kubeconfig is required.")
+	flags.StringVar(&o.onlyCheck, "only-check", "", "Only find out whether the objects meet this level, e.g. \"restricted\", which saves evaluating the other levels for the ones that do. The objects are not evaluated against the more restrictive levels, they are reported at this level at most. One of: privileged|baseline|restricted.")
+	flags.IntVar(&o.admissionOptions.MaxConcurrencyPerNamespace, "workers-per-namespace", 0, "Advanced: the maximum number of objects of the same namespace to evaluate at the same time, within the --max-concurrency. The objects are then evaluated by turns of the namespaces. Zero means no limit other than --max-concurrency.")
+	flags.BoolVar(&o.printOptions.Explain, "explain", false, "Print the PodSecurity checks that each object failed along with the level they require.")
 }
 
 func (o *WorkloadInspectOptions) Complete(cmd *cobra.Command, args []string, clientConfigOptions *genericclioptions.ConfigFlags) error {
 	o.updatesOnly = cmdutil.GetFlagBool(cmd, "updates-only")
-	o.clientConfigOptions = clientConfigOptions
-
-	clientConfig, err := o.clientConfigOptions.ToRawKubeConfigLoader().ClientConfig()
-	if err != nil {
+	o.compareLabels = cmdutil.GetFlagBool(cmd, "compare-labels")
+	if err := o.printOptions.Complete(cmd); err != nil {
 		return err
 	}
-
-	o.kubeClient, err = kubernetes.NewForConfig(clientConfig)
+	if maxLevel := cmdutil.GetFlagString(cmd, "max-level"); len(maxLevel) > 0 {
+		level, err := psapi.ParseLevel(maxLevel)
+		if err != nil {
+			return fmt.Errorf("invalid --max-level value: %w", err)
+		}
+		o.maxLevel = level
+		o.printOptions.MaxLevel = level
+	}
+	if targetLevel := cmdutil.GetFlagString(cmd, "target-level"); len(targetLevel) > 0 {
+		level, err := psapi.ParseLevel(targetLevel)
+		if err != nil {
+			return fmt.Errorf("invalid --target-level value: %w", err)
+		}
+		o.targetLevel = level
+		o.printOptions.TargetLevel = level
+	}
+	// sets the policy versions of the unlabeled namespaces as well
+	psaConfig, err := config.LoadPodSecurityConfig(cmd)
 	if err != nil {
 		return err
 	}
+	// only the objects in the cluster are evaluated against the version of the server
+	var server discovery.ServerVersionInterface
+	if !o.hasLocalFiles() {
+		// the builder maps the resource types with the same client, the
+		// discovered resources are cached in the --cache-dir across the runs
+		cachedDiscovery, err := clientConfigOptions.ToDiscoveryClient()
+		if err != nil {
+			return err
+		}
+		if cmdutil.GetFlagBool(cmd, "flush-cache") {
+			cachedDiscovery.Invalidate()
+		}
+		server = cachedDiscovery
+	}
+	// several versions can be compared, e.g. "v1.22,v1.23,latest"
+	var versions []psapi.Version
+	for _, versionValue := range strings.Split(cmdutil.GetFlagString(cmd, "policy-version"), ",") {
+		var version psapi.Version
+		var fromServer bool
+		version, fromServer, err = admission.ParsePolicyVersion(strings.TrimSpace(versionValue), server, cmdutil.GetFlagInt(cmd, "max-retries"))
+		if err != nil {
+			return fmt.Errorf("failed to resolve --policy-version=%s: %w", versionValue, err)
+		}
+		if fromServer {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Evaluating against the PodSecurity policy version %s of the server\n", version)
+		}
+		versions = append(versions, version)
+	}
+	policyVersion := versions[0]
+	o.comparedVersions = nil
+	if len(versions) > 1 {
+		o.comparedVersions = versions
+	}
+	o.admissionOptions.PolicyVersions = admission.PolicyVersions{Enforce: policyVersion, Warn: policyVersion, Audit: policyVersion}
+	for flag, version := range map[string]*psapi.Version{
+		"warn-policy-version":  &o.admissionOptions.PolicyVersions.Warn,
+		"audit-policy-version": &o.admissionOptions.PolicyVersions.Audit,
+	} {
+		if v := cmdutil.GetFlagString(cmd, flag); len(v) > 0 {
+			if *version, err = psapi.ParseVersion(v); err != nil {
+				return fmt.Errorf("invalid --%s value: %w", flag, err)
+			}
+		}
+	}
+	o.nsVersionLabel = psapi.EnforceVersionLabel
+	if cmdutil.GetFlagBool(cmd, "use-warn-level") {
+		o.nsVersionLabel = psapi.WarnVersionLabel
+		// the reported level is evaluated the same way as the warn level, the
+		// generated labels pin the warn version so that they match the level
+		policyVersion = o.admissionOptions.PolicyVersions.Warn
+		o.admissionOptions.PolicyVersions.Enforce = policyVersion
+	}
+	o.printOptions.PolicyVersions = o.admissionOptions.PolicyVersions
+
+	if cmdutil.GetFlagBool(cmd, "apply") {
+		dryRun, err := cmdutil.GetDryRunStrategy(cmd)
+		if err != nil {
+			return err
+		}
+		o.applyOptions = &nslabels.ApplyOptions{
+			DryRun:        dryRun,
+			AllowRelax:    cmdutil.GetFlagBool(cmd, "allow-relax"),
+			PolicyVersion: policyVersion,
+		}
+	}
+	if len(o.securityContext) > 0 {
+		if o.podSpecPatch, err = parsePodSpecPatch(o.securityContext); err != nil {
+			return fmt.Errorf("invalid --with-security-context value: %w", err)
+		}
+	}
+	o.clientConfigOptions = clientConfigOptions
+	o.errOut = cmd.ErrOrStderr()
+	// in-cluster runs default to the namespace of their pod, the same way
+	// the in-cluster client config does
+	if len(*o.clientConfigOptions.Namespace) == 0 && !o.allNamespaces && len(o.namespaces) == 0 {
+		if ns := inClusterNamespace(); len(ns) > 0 {
+			klog.V(2).InfoS("Defaulting --namespace to the namespace of the pod", "namespace", ns)
+			*o.clientConfigOptions.Namespace = ns
+		}
+	}
+
+	o.admissionOptions.MaxConcurrency = cmdutil.GetFlagInt(cmd, "max-concurrency")
+	o.maxRetries = cmdutil.GetFlagInt(cmd, "max-retries")
+	o.admissionOptions.Exemptions = psadmissionapi.PodSecurityExemptions{
+		Namespaces:     cmdutil.GetFlagStringSlice(cmd, "exempt-namespace"),
+		RuntimeClasses: cmdutil.GetFlagStringSlice(cmd, "exempt-runtime-class"),
+		Usernames:      cmdutil.GetFlagStringSlice(cmd, "exempt-user"),
+	}
+	if psaConfig != nil {
+		o.admissionOptions.Exemptions = admission.MergeExemptions(o.admissionOptions.Exemptions, psaConfig.Exemptions)
+		o.psaDefaults = &psaConfig.Defaults
+	}
+	o.admissionOptions.IgnoredChecks = cmdutil.GetFlagStringSlice(cmd, "ignore-control")
+	o.admissionOptions.OnlyCheck = ""
+	if len(o.onlyCheck) > 0 {
+		if o.admissionOptions.OnlyCheck, err = psapi.ParseLevel(o.onlyCheck); err != nil {
+			return fmt.Errorf("invalid --only-check value: %w", err)
+		}
+	}
+	if progress.Enabled(cmd) {
+		o.admissionOptions.Progress = progress.NewReporter(cmd.ErrOrStderr()).Report
+	}
+	// the exempt users are matched against the user the objects would be created by
+	o.admissionOptions.Username = *o.clientConfigOptions.Impersonate
 
-	o.builder = resource.NewBuilder(o.clientConfigOptions).
-		WithScheme(scheme,
-			corev1.SchemeGroupVersion,
-			appsv1.SchemeGroupVersion,
-			batchv1.SchemeGroupVersion,
-		)
+	o.builder = resource.NewBuilder(o.clientConfigOptions)
+
+	if ns := *o.clientConfigOptions.Namespace; len(ns) > 0 && !o.allNamespaces {
+		o.builder = o.builder.
+			NamespaceParam(ns).
+			DefaultNamespace()
+	}
 
 	// make the builder accept files if provided, otherwise expect resourceType and name
-	if files := o.filenameOptions.Filenames; len(files) > 0 {
+	if o.hasLocalFiles() {
+		filenameOptions, readStdin := withoutStdinFilename(o.filenameOptions)
+		filenameOptions, err := withGlobsExpanded(filenameOptions)
+		if err != nil {
+			return err
+		}
+		filenameOptions, err = withIgnoredFilesRemoved(filenameOptions)
+		if err != nil {
+			return err
+		}
+		if len(o.changedSince) > 0 {
+			changed, inRepo, err := changedFiles(o.changedSince)
+			if err != nil {
+				return err
+			}
+			if !inRepo {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: not in a git repository, inspecting all the files instead of the ones changed since %q\n", o.changedSince)
+			} else if filenameOptions, err = withUnchangedFilesRemoved(filenameOptions, changed); err != nil {
+				return err
+			}
+			o.noChangedFiles = len(filenameOptions.Filenames) == 0 && len(filenameOptions.Kustomize) == 0 &&
+				!readStdin && len(o.helmChart) == 0 && len(o.archives) == 0
+			if o.noChangedFiles {
+				fmt.Fprintf(cmd.ErrOrStderr(), "No files changed since %q\n", o.changedSince)
+			}
+		}
+
+		// the recursive directory traversal (-R) is configured in the filename options,
+		// the boolean here only controls whether the objects are required to have a namespace
+		// the documents are decoded as unstructured so that the ones of kinds
+		// unknown to the scheme can be reported by their names, see typedInfos().
+		// The items of Lists, e.g. saved `kubectl get -o json` output, are
+		// evaluated as separate objects.
 		o.builder = o.builder.
+			Unstructured().
 			Local().
-			FilenameParam(false, o.filenameOptions)
+			FilenameParam(false, filenameOptions).
+			Flatten()
+		if !o.strictParse {
+			o.builder = o.builder.
+				ContinueOnError()
+		}
+
+		if len(o.helmChart) > 0 {
+			rendered, err := renderHelmChart(o.helmChart, o.helmValues, *o.clientConfigOptions.Namespace)
+			if err != nil {
+				return err
+			}
+			o.builder = o.builder.
+				Stream(bytes.NewReader(rendered), o.helmChart)
+		}
+
+		for _, archive := range o.archives {
+			entries, err := readArchive(archive)
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				o.builder = o.builder.
+					Stream(bytes.NewReader(entry.data), fmt.Sprintf("%s:%s", archive, entry.name))
+			}
+		}
+
+		if readStdin {
+			// read the command's input rather than letting the builder go for os.Stdin directly
+			o.builder = o.builder.
+				StdinInUse().
+				Stream(cmd.InOrStdin(), "STDIN")
+		}
 
 		o.isLocal = true
+		if o.offline {
+			// the local files are evaluated against the mocked namespaces only
+			return nil
+		}
+	} else if len(o.namespaces) > 0 {
+		// a builder can only list a single namespace
+		o.namespaceBuilders = nil
+		for _, ns := range o.namespaces {
+			o.namespaceBuilders = append(o.namespaceBuilders, o.clusterBuilder(resource.NewBuilder(o.clientConfigOptions).NamespaceParam(ns), args))
+		}
 	} else {
-		o.builder = o.builder.
-			SingleResourceType().
-			ResourceTypeOrNameArgs(true, args...)
+		o.builder = o.clusterBuilder(o.builder, args)
+	}
+	if !o.isLocal {
+		// the builder would only report that the server does not have the type
+		mapper, err := o.clientConfigOptions.ToRESTMapper()
+		if err != nil {
+			return err
+		}
+		if err := validateResourceTypes(mapper, args, o.resolveScaleTarget); err != nil {
+			return err
+		}
 	}
 
-	if ns := *o.clientConfigOptions.Namespace; len(ns) > 0 {
-		o.builder = o.builder.
-			NamespaceParam(ns).
-			DefaultNamespace()
+	clientConfig, err := o.clientConfigOptions.ToRawKubeConfigLoader().ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	o.kubeClient, err = kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		return err
 	}
 
 	return nil
@@ -106,7 +412,17 @@ func (o *WorkloadInspectOptions) Complete(cmd *cobra.Command, args []string, cli
 func (o *WorkloadInspectOptions) Validate() []error {
 	errs := []error{}
 
-	if o.kubeClient == nil {
+	if o.offline {
+		if !o.isLocal {
+			errs = append(errs, fmt.Errorf("--offline can only be used with local files"))
+		}
+		if o.applyOptions != nil {
+			errs = append(errs, fmt.Errorf("--offline and --apply are mutually exclusive"))
+		}
+		if o.failOnMissingNS {
+			errs = append(errs, fmt.Errorf("--offline and --fail-on-missing-namespace are mutually exclusive"))
+		}
+	} else if o.kubeClient == nil {
 		errs = append(errs, fmt.Errorf("missing kube client"))
 	}
 
@@ -114,47 +430,503 @@ func (o *WorkloadInspectOptions) Validate() []error {
 		errs = append(errs, fmt.Errorf("cannot specify --default-namespaces without also providing a value for --namespace"))
 	}
 
+	if len(o.namespaceOverride) > 0 {
+		if o.defaultNamespaces {
+			errs = append(errs, fmt.Errorf("--namespace-override and --default-namespaces are mutually exclusive"))
+		}
+		if !o.isLocal {
+			errs = append(errs, fmt.Errorf("--namespace-override can only be used with local files"))
+		}
+	}
+
+	if len(o.helmValues) > 0 && len(o.helmChart) == 0 {
+		errs = append(errs, fmt.Errorf("--values requires a --helm-chart"))
+	}
+
+	if o.strictNamespaces && len(*o.clientConfigOptions.Namespace) == 0 {
+		errs = append(errs, fmt.Errorf("cannot specify --strict-namespaces without also providing a value for --namespace"))
+	}
+
+	if o.maxRetries < 0 {
+		errs = append(errs, fmt.Errorf("--max-retries must not be negative"))
+	}
+
+	if len(o.comparedVersions) > 0 {
+		if o.applyOptions != nil {
+			errs = append(errs, fmt.Errorf("--apply cannot be used with several --policy-version values"))
+		}
+		if o.printOptions.GenerateLabels {
+			errs = append(errs, fmt.Errorf("--generate-labels cannot be used with several --policy-version values"))
+		}
+	}
+
+	if o.includeSystemPods && !o.allNamespaces {
+		errs = append(errs, fmt.Errorf("--include-system-pods requires --all-namespaces"))
+	}
+
+	if o.strict && len(o.targetLevel) == 0 {
+		errs = append(errs, fmt.Errorf("--strict requires a --target-level"))
+	}
+
+	if o.resolveOwners && o.isLocal {
+		errs = append(errs, fmt.Errorf("--resolve-owners cannot be used with local files"))
+	}
+
+	if o.resolveScaleTarget && o.isLocal {
+		errs = append(errs, fmt.Errorf("--resolve-scale-target cannot be used with local files"))
+	}
+
+	if len(o.changedSince) > 0 && len(o.filenameOptions.Filenames) == 0 {
+		errs = append(errs, fmt.Errorf("--changed-since requires files passed by -f"))
+	}
+
+	if len(o.fieldSelector) > 0 && o.isLocal {
+		errs = append(errs, fmt.Errorf("--field-selector cannot be used with local files"))
+	}
+
+	if o.checkReplicaSets && o.isLocal {
+		errs = append(errs, fmt.Errorf("--check-replicasets cannot be used with local files"))
+	}
+
+	if o.allNamespaces && o.isLocal {
+		errs = append(errs, fmt.Errorf("--all-namespaces cannot be used with local files"))
+	}
+
+	if len(o.namespaces) > 0 {
+		if o.isLocal {
+			errs = append(errs, fmt.Errorf("--namespaces cannot be used with local files"))
+		}
+		if o.allNamespaces {
+			errs = append(errs, fmt.Errorf("--namespaces and --all-namespaces are mutually exclusive"))
+		}
+		if len(*o.clientConfigOptions.Namespace) > 0 {
+			errs = append(errs, fmt.Errorf("--namespaces and --namespace are mutually exclusive"))
+		}
+	}
+
+	for _, pattern := range o.excludePatterns() {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid --exclude-namespace pattern %q: %w", pattern, err))
+		}
+	}
+
+	if err := printers.ValidateFormat(o.printOptions.Format); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := printers.ValidateSortBy(o.printOptions.SortBy); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := printers.ValidateGroupBy(o.printOptions.GroupBy, o.printOptions.Format); err != nil {
+		errs = append(errs, err)
+	}
+
 	return errs
 }
 
-func (opts *WorkloadInspectOptions) Run(ctx context.Context) (*admission.OrderedStringToPSALevelMap, error) {
-	adm, err := admission.NewParallelAdmission(opts.kubeClient)
+func (opts *WorkloadInspectOptions) Run(ctx context.Context) (*admission.OrderedNamespaceResultsMap, error) {
+	var nsAggregatedResults map[string]*admission.NamespaceResult
+
+	if opts.noChangedFiles {
+		return admission.NewOrderedNamespaceResultsMap(map[string]*admission.NamespaceResult{}), nil
+	}
+
+	var infos []*resource.Info
+	var err error
+	opts.parseErrors = nil
+	if opts.isLocal {
+		// the standard input can only be read once
+		infos, err = opts.builder.Do().Infos()
+		// the errors of the builder setup come without any infos, e.g. a
+		// missing file, the ones of the documents are collected unless the
+		// builder fails fast with --strict-parse
+		if err != nil && infos != nil && !opts.strictParse {
+			if agg, ok := err.(utilerrors.Aggregate); ok {
+				opts.parseErrors = utilerrors.Flatten(agg).Errors()
+			} else {
+				opts.parseErrors = []error{err}
+			}
+			err = nil
+		}
+	} else {
+		builders := opts.namespaceBuilders
+		if len(builders) == 0 {
+			builders = []*resource.Builder{opts.builder}
+		}
+		// the result keeps the error, each retry needs a new one
+		err = apiretry.OnError(opts.maxRetries, func() error {
+			infos = nil
+			for _, builder := range builders {
+				builderInfos, err := builder.Do().Infos()
+				if err != nil {
+					return err
+				}
+				infos = append(infos, builderInfos...)
+			}
+			return nil
+		})
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to set up admission: %w", err)
+		return nil, fmt.Errorf("failed to retrieve info about the objects: %w", err)
 	}
+	klog.V(2).InfoS("Retrieved the objects to evaluate", "count", len(infos), "local", opts.isLocal)
 
-	var nsAggregatedResults map[string]psapi.Level
+	var resolvedFrom map[string]map[string][]string
+	if opts.resolveScaleTarget {
+		if infos, resolvedFrom, err = resolveScaleTargets(ctx, opts.kubeClient, infos, opts.errOut); err != nil {
+			return nil, err
+		}
+	}
 
-	res := opts.builder.Do()
+	var apiWarnings map[runtime.Object]string
+	if opts.isLocal {
+		var invalid []error
+		infos, apiWarnings, invalid = typedInfos(infos)
+		if len(invalid) > 0 && opts.strictParse {
+			return nil, utilerrors.NewAggregate(invalid)
+		}
+		opts.parseErrors = append(opts.parseErrors, invalid...)
+		for _, info := range infos {
+			if apiWarning, ok := apiWarnings[info.Object]; ok {
+				fmt.Fprintf(opts.errOut, "Warning: %s/%s in %s: %s\n", info.Object.GetObjectKind().GroupVersionKind().Kind, info.Name, info.Source, apiWarning)
+			}
+		}
+	}
 
-	infos, err := res.Infos()
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve info about the objects: %w", err)
+	// the kubelets run the static pods regardless of the labels of their
+	// namespaces, their mirror pods are only evaluated when asked for
+	mirrorPods := map[admission.AdmissionResultsKey]bool{}
+	if opts.allNamespaces {
+		filteredInfos := make([]*resource.Info, 0, len(infos))
+		for _, info := range infos {
+			if !isMirrorPod(info.Object) {
+				if !opts.namespaceExcluded(info.Namespace) {
+					filteredInfos = append(filteredInfos, info)
+				}
+				continue
+			}
+			if opts.includeSystemPods {
+				mirrorPods[admission.AdmissionResultsKey{GVK: info.Object.GetObjectKind().GroupVersionKind(), Namespace: info.Namespace, Name: info.Name}] = true
+				filteredInfos = append(filteredInfos, info)
+			}
+		}
+		infos = filteredInfos
 	}
 
-	var defaultNS *string
+	if opts.resolveOwners {
+		if infos, resolvedFrom, err = resolveOwners(ctx, opts.kubeClient, infos, resolvedFrom); err != nil {
+			return nil, err
+		}
+	}
+	infos, resolvedFrom = withoutCronJobJobs(infos, resolvedFrom)
+
+	if opts.checkReplicaSets {
+		if infos, err = withOwnedReplicaSets(ctx, opts.kubeClient, infos, opts.maxRetries); err != nil {
+			return nil, err
+		}
+	}
+
+	checkOptions := &checker.Options{ParallelAdmissionOptions: *opts.admissionOptions}
 	if opts.defaultNamespaces {
-		defaultNS = opts.clientConfigOptions.Namespace
+		checkOptions.DefaultNamespace = *opts.clientConfigOptions.Namespace
+	}
+	if ns := *opts.clientConfigOptions.Namespace; opts.isLocal && len(ns) > 0 && len(opts.namespaceOverride) == 0 {
+		if err := opts.checkNamespaceMismatches(infos, ns); err != nil {
+			return nil, err
+		}
+	}
+	if len(opts.namespaceOverride) > 0 {
+		for _, info := range infos {
+			info.Object.(metav1.ObjectMetaAccessor).GetObjectMeta().SetNamespace(opts.namespaceOverride)
+			info.Namespace = opts.namespaceOverride
+		}
 	}
 
-	results, err := adm.ValidateResources(ctx, opts.isLocal, defaultNS, infos...)
+	objects := make([]runtime.Object, 0, len(infos))
+	for _, info := range infos {
+		if len(opts.podSpecPatch) > 0 {
+			if err := patchPodSpec(info.Object, opts.podSpecPatch); err != nil {
+				return nil, fmt.Errorf("failed to merge --with-security-context onto %s/%s: %w", info.Object.GetObjectKind().GroupVersionKind().Kind, info.Name, err)
+			}
+		}
+		objects = append(objects, info.Object)
+	}
+	// the live namespaces are cached for the duration of this run only so that they don't go stale
+	nsGetter := admission.NewCachingNamespaceGetter(
+		admission.NewRetryingNamespaceGetter(psadmission.NamespaceGetterFromClient(opts.kubeClient), opts.maxRetries),
+	)
+	// the compared versions are all applied to every namespace regardless of their pins
+	if !opts.isLocal && len(opts.comparedVersions) == 0 {
+		checkOptions.NamespaceGetter = nsGetter
+		checkOptions.VersionLabel = opts.nsVersionLabel
+	}
+	results, err := checker.Check(ctx, opts.kubeClient, objects, checkOptions)
 	if err != nil {
 		return nil, err
 	}
-	nsAggregatedResults = admission.MostRestrictivePolicyPerNamespace(results)
-	if !opts.isLocal && opts.updatesOnly {
+	nsAggregatedResults = admission.AggregateResultsPerNamespace(results)
+	setPinnedVersions(nsAggregatedResults, checkOptions.PolicyVersions.Enforce)
+	// the objects were defaulted to their namespaces by the checks
+	for obj, apiWarning := range apiWarnings {
+		objMeta := obj.(metav1.ObjectMetaAccessor).GetObjectMeta()
+		nsResult, ok := nsAggregatedResults[objMeta.GetNamespace()]
+		if !ok {
+			continue
+		}
+		for _, objResult := range nsResult.Objects {
+			if objResult.Kind == obj.GetObjectKind().GroupVersionKind().Kind && objResult.Name == objMeta.GetName() {
+				objResult.DeprecatedAPI = apiWarning
+			}
+		}
+	}
+	if len(mirrorPods) > 0 {
+		markStaticPods(nsAggregatedResults, mirrorPods)
+	}
+	setResolvedFrom(nsAggregatedResults, resolvedFrom)
+	if opts.allNamespaces {
+		// namespaces without any workloads would not appear in the results otherwise
+		var namespaces *corev1.NamespaceList
+		err := apiretry.OnError(opts.maxRetries, func() error {
+			var err error
+			namespaces, err = opts.kubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		for _, ns := range namespaces.Items {
+			if _, ok := nsAggregatedResults[ns.Name]; !ok && !opts.namespaceExcluded(ns.Name) {
+				nsAggregatedResults[ns.Name] = emptyNamespaceResult()
+			}
+		}
+	}
+	for _, ns := range opts.namespaces {
+		if _, ok := nsAggregatedResults[ns]; !ok {
+			nsAggregatedResults[ns] = emptyNamespaceResult()
+		}
+	}
+	if len(opts.comparedVersions) > 0 {
+		if err := compareVersions(ctx, opts.kubeClient, objects, checkOptions, opts.comparedVersions, nsAggregatedResults); err != nil {
+			return nil, err
+		}
+	}
+	if len(opts.targetLevel) > 0 {
+		for _, nsResult := range nsAggregatedResults {
+			nsResult.CompareToTargetLevel(opts.targetLevel)
+		}
+	}
+	// the namespaces of local files are only looked up if asked for so that
+	// evaluating them does not require access to the cluster
+	if !opts.isLocal || opts.failOnMissingNS {
 		// TODO: list the NSes we've got in the map at the same time instead of going 1-by-1?
-		for ns, level := range nsAggregatedResults {
-			liveNS, err := opts.kubeClient.CoreV1().Namespaces().Get(ctx, ns, metav1.GetOptions{})
-			if err != nil {
+		for ns, nsResult := range nsAggregatedResults {
+			liveNS, err := nsGetter.GetNamespace(ctx, ns)
+			exists := err == nil
+			if apierrors.IsNotFound(err) && !opts.failOnMissingNS {
+				// the same as a namespace without any PodSecurity labels
+				liveNS = &corev1.Namespace{}
+			} else if apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("namespace %q does not exist", ns)
+			} else if err != nil {
 				return nil, err
 			}
-			// FIXME: need to take the global config into account
-			if string(level) == liveNS.Labels[psapi.EnforceLevelLabel] {
+			nsResult.NamespaceExists = &exists
+			if opts.isLocal {
+				continue
+			}
+
+			nsLabels := admission.WithDefaultLabels(liveNS.Labels, opts.psaDefaults)
+			nsResult.MarkRejectedObjects(nsLabels)
+			nsResult.RecordAuditAnnotations(nsLabels)
+			if opts.compareLabels {
+				nsResult.CompareLabels(nsLabels)
+			}
+			if opts.updatesOnly && nsResult.Level.String() == nsLabels[psapi.EnforceLevelLabel] {
 				delete(nsAggregatedResults, ns)
 			}
 		}
 	}
 
-	return admission.NewOrderedStringToPSALevelMap(nsAggregatedResults), nil
+	return admission.NewOrderedNamespaceResultsMap(nsAggregatedResults), nil
+}
+
+// printParseErrors lists the errors of the documents of local files that were
+// left out of the last run
+func (o *WorkloadInspectOptions) printParseErrors(w io.Writer) {
+	if len(o.parseErrors) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Warning: left out the documents of the local files that could not be evaluated:\n")
+	for _, err := range o.parseErrors {
+		fmt.Fprintf(w, "    %v\n", err)
+	}
+}
+
+// hasLocalFiles returns whether the objects come from local files, including
+// the rendered helm charts and archives, rather than from the cluster
+func (o *WorkloadInspectOptions) hasLocalFiles() bool {
+	return len(o.filenameOptions.Filenames) > 0 || len(o.filenameOptions.Kustomize) > 0 || len(o.helmChart) > 0 || len(o.archives) > 0
+}
+
+// withoutStdinFilename returns a copy of the filename options without the "-"
+// filename and whether it was requested to read the resources from standard input
+func withoutStdinFilename(filenameOptions *resource.FilenameOptions) (*resource.FilenameOptions, bool) {
+	ret := *filenameOptions
+	ret.Filenames = make([]string, 0, len(filenameOptions.Filenames))
+
+	readStdin := false
+	for _, f := range filenameOptions.Filenames {
+		if f == "-" {
+			readStdin = true
+			continue
+		}
+		ret.Filenames = append(ret.Filenames, f)
+	}
+	return &ret, readStdin
+}
+
+// withGlobsExpanded returns a copy of the filename options with the glob patterns
+// that don't name existing paths replaced by the paths matching them, so that
+// the patterns work even when they are not expanded by the shell
+func withGlobsExpanded(filenameOptions *resource.FilenameOptions) (*resource.FilenameOptions, error) {
+	ret := *filenameOptions
+	ret.Filenames = make([]string, 0, len(filenameOptions.Filenames))
+
+	for _, f := range filenameOptions.Filenames {
+		if strings.HasPrefix(f, "http://") || strings.HasPrefix(f, "https://") || !strings.ContainsAny(f, "*?[") {
+			ret.Filenames = append(ret.Filenames, f)
+			continue
+		}
+		if _, err := os.Stat(f); err == nil {
+			ret.Filenames = append(ret.Filenames, f)
+			continue
+		}
+
+		matches, err := filepath.Glob(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filename pattern %q: %w", f, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match the filename pattern %q", f)
+		}
+		ret.Filenames = append(ret.Filenames, matches...)
+	}
+	return &ret, nil
+}
+
+// clusterBuilder sets the builder up to retrieve the objects of the args
+// from the cluster, all the supported workloads if there are none and the
+// namespaces are listed by --all-namespaces or --namespaces
+func (o *WorkloadInspectOptions) clusterBuilder(builder *resource.Builder, args []string) *resource.Builder {
+	if o.resolveScaleTarget {
+		// the HPAs are not known to the scheme, the objects are converted
+		// to its types once the HPAs are resolved, see resolveScaleTargets()
+		builder = builder.
+			Unstructured()
+	} else {
+		builder = builder.
+			WithScheme(scheme,
+				corev1.SchemeGroupVersion,
+				appsv1.SchemeGroupVersion,
+				batchv1.SchemeGroupVersion,
+			)
+	}
+
+	if (o.allNamespaces || len(o.namespaces) > 0) && len(args) == 0 {
+		builder = builder.
+			ResourceTypeOrNameArgs(true, supportedResourceTypes())
+	} else {
+		// several types can be listed at once, e.g. "deployments,statefulsets",
+		// the results of all of them are aggregated per namespace
+		builder = builder.
+			ResourceTypeOrNameArgs(true, withAllTypeExpanded(args)...)
+	}
+
+	return builder.
+		FieldSelectorParam(o.fieldSelector).
+		AllNamespaces(o.allNamespaces).
+		Flatten()
+}
+
+// supportedResourceTypes returns the comma-separated list of the resource types
+// that carry a pod spec which this tool is able to evaluate
+func supportedResourceTypes() string {
+	resources := (&psadmission.DefaultPodSpecExtractor{}).PodSpecResources()
+
+	types := make([]string, 0, len(resources))
+	for _, gr := range resources {
+		types = append(types, gr.String())
+	}
+	sort.Strings(types)
+
+	return strings.Join(types, ",")
+}
+
+// withAllTypeExpanded returns a copy of the args with the "all" resource type
+// replaced by the supported workload types. The "all" category of the server
+// would also list the resources without a pod template, e.g. the services.
+func withAllTypeExpanded(args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+
+	types := strings.Split(args[0], ",")
+	for i, t := range types {
+		if t == "all" {
+			types[i] = supportedResourceTypes()
+		}
+	}
+	return append([]string{strings.Join(types, ",")}, args[1:]...)
+}
+
+// excludePatterns returns the --exclude-namespace patterns along with the default ones
+func (o *WorkloadInspectOptions) excludePatterns() []string {
+	if o.noDefaultExcludes {
+		return o.excludeNamespaces
+	}
+	return append(append([]string{}, o.excludeNamespaces...), defaultExcludedNamespaces...)
+}
+
+// emptyNamespaceResult is the result of the namespaces without any workloads
+func emptyNamespaceResult() *admission.NamespaceResult {
+	return &admission.NamespaceResult{
+		Level:      admission.LevelRestrictedValue,
+		WarnLevel:  admission.LevelRestrictedValue,
+		AuditLevel: admission.LevelRestrictedValue,
+		Objects:    []*admission.ObjectResult{},
+	}
+}
+
+func (o *WorkloadInspectOptions) namespaceExcluded(ns string) bool {
+	for _, pattern := range o.excludePatterns() {
+		// the patterns were validated already
+		if matched, _ := path.Match(pattern, ns); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNamespaceMismatches warns about the objects from files that are in a
+// different namespace than the --namespace value, which is often a copy-paste
+// mistake. They are reported as an error with --strict-namespaces.
+func (o *WorkloadInspectOptions) checkNamespaceMismatches(infos []*resource.Info, namespace string) error {
+	var mismatched []string
+	for _, info := range infos {
+		objNamespace := info.Object.(metav1.ObjectMetaAccessor).GetObjectMeta().GetNamespace()
+		if len(objNamespace) == 0 || objNamespace == namespace {
+			continue
+		}
+		obj := fmt.Sprintf("%s/%s in namespace %q (%s)", info.Object.GetObjectKind().GroupVersionKind().Kind, info.Name, objNamespace, info.Source)
+		if !o.strictNamespaces {
+			fmt.Fprintf(o.errOut, "Warning: %s does not match --namespace %q\n", obj, namespace)
+		}
+		mismatched = append(mismatched, obj)
+	}
+
+	if o.strictNamespaces && len(mismatched) > 0 {
+		return fmt.Errorf("objects do not match --namespace %q: %s", namespace, strings.Join(mismatched, ", "))
+	}
+	return nil
 }
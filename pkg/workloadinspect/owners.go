@@ -0,0 +1,145 @@
+package workloadinspect
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// resolveOwners replaces the pods by their top controllers, the pods of the
+// same controller are replaced by a single info. The replaced pods are added
+// to the resolvedFrom map, keyed by the namespace and the "Kind/name" of their controllers.
+func resolveOwners(ctx context.Context, client kubernetes.Interface, infos []*resource.Info, resolvedFrom map[string]map[string][]string) ([]*resource.Info, map[string]map[string][]string, error) {
+	if resolvedFrom == nil {
+		resolvedFrom = map[string]map[string][]string{}
+	}
+	resolved := make([]*resource.Info, 0, len(infos))
+	seen := map[admission.AdmissionResultsKey]bool{}
+
+	for _, info := range infos {
+		obj := info.Object
+		if pod, ok := obj.(*corev1.Pod); ok {
+			owner, err := topController(ctx, client, pod)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to resolve the owner of pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+			if owner != pod {
+				ownerMeta := owner.(metav1.Object)
+				ownerName := fmt.Sprintf("%s/%s", owner.GetObjectKind().GroupVersionKind().Kind, ownerMeta.GetName())
+				if resolvedFrom[pod.Namespace] == nil {
+					resolvedFrom[pod.Namespace] = map[string][]string{}
+				}
+				resolvedFrom[pod.Namespace][ownerName] = append(resolvedFrom[pod.Namespace][ownerName], fmt.Sprintf("Pod/%s", pod.Name))
+				klog.V(4).InfoS("Resolved the owner of pod", "pod", klog.KObj(pod), "owner", ownerName)
+
+				info = &resource.Info{
+					Object:    owner,
+					Namespace: ownerMeta.GetNamespace(),
+					Name:      ownerMeta.GetName(),
+					Source:    info.Source,
+				}
+			}
+		}
+
+		id := admission.AdmissionResultsKey{GVK: info.Object.GetObjectKind().GroupVersionKind(), Namespace: info.Namespace, Name: info.Name}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		resolved = append(resolved, info)
+	}
+	return resolved, resolvedFrom, nil
+}
+
+// setResolvedFrom sets the ResolvedFrom of the objects of the results. The
+// namespaces of resolvedFrom may be missing from the results, e.g. the
+// objects of the excluded namespaces were filtered out after they were resolved.
+func setResolvedFrom(nsResults map[string]*admission.NamespaceResult, resolvedFrom map[string]map[string][]string) {
+	for ns, nsResolvedFrom := range resolvedFrom {
+		nsResult, ok := nsResults[ns]
+		if !ok {
+			continue
+		}
+		for _, obj := range nsResult.Objects {
+			obj.ResolvedFrom = nsResolvedFrom[obj.Kind+"/"+obj.Name]
+		}
+	}
+}
+
+// topController walks the controller owner references of the object for as
+// long as the owners are of the supported workload kinds and exist
+func topController(ctx context.Context, client kubernetes.Interface, obj runtime.Object) (runtime.Object, error) {
+	for {
+		objMeta := obj.(metav1.Object)
+		ref := metav1.GetControllerOf(objMeta)
+		if ref == nil {
+			return obj, nil
+		}
+
+		owner, err := getController(ctx, client, objMeta.GetNamespace(), ref)
+		if apierrors.IsNotFound(err) {
+			return obj, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if owner == nil {
+			// e.g. an operator's custom resource, its pod template is unknown
+			return obj, nil
+		}
+		obj = owner
+	}
+}
+
+func getController(ctx context.Context, client kubernetes.Interface, namespace string, ref *metav1.OwnerReference) (runtime.Object, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj runtime.Object
+	var gvk schema.GroupVersionKind
+	switch gk := (schema.GroupKind{Group: gv.Group, Kind: ref.Kind}); gk {
+	case schema.GroupKind{Group: appsv1.GroupName, Kind: "ReplicaSet"}:
+		obj, err = client.AppsV1().ReplicaSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		gvk = appsv1.SchemeGroupVersion.WithKind(gk.Kind)
+	case schema.GroupKind{Group: appsv1.GroupName, Kind: "Deployment"}:
+		obj, err = client.AppsV1().Deployments(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		gvk = appsv1.SchemeGroupVersion.WithKind(gk.Kind)
+	case schema.GroupKind{Group: appsv1.GroupName, Kind: "StatefulSet"}:
+		obj, err = client.AppsV1().StatefulSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		gvk = appsv1.SchemeGroupVersion.WithKind(gk.Kind)
+	case schema.GroupKind{Group: appsv1.GroupName, Kind: "DaemonSet"}:
+		obj, err = client.AppsV1().DaemonSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		gvk = appsv1.SchemeGroupVersion.WithKind(gk.Kind)
+	case schema.GroupKind{Group: batchv1.GroupName, Kind: "Job"}:
+		obj, err = client.BatchV1().Jobs(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		gvk = batchv1.SchemeGroupVersion.WithKind(gk.Kind)
+	case schema.GroupKind{Group: batchv1.GroupName, Kind: "CronJob"}:
+		obj, err = client.BatchV1().CronJobs(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		gvk = batchv1.SchemeGroupVersion.WithKind(gk.Kind)
+	case schema.GroupKind{Group: corev1.GroupName, Kind: "ReplicationController"}:
+		obj, err = client.CoreV1().ReplicationControllers(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		gvk = corev1.SchemeGroupVersion.WithKind(gk.Kind)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the typed clients don't set the kind of the objects
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	return obj, nil
+}
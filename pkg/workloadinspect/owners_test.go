@@ -0,0 +1,69 @@
+package workloadinspect
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+func newHPAInfo(namespace, name, target string) *resource.Info {
+	hpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling/v2",
+		"kind":       "HorizontalPodAutoscaler",
+		"metadata": map[string]interface{}{
+			"namespace": namespace,
+			"name":      name,
+		},
+		"spec": map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"name":       target,
+			},
+		},
+	}}
+	return &resource.Info{Object: hpa, Namespace: namespace, Name: name}
+}
+
+func TestSetResolvedFromFilteredNamespaces(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "metrics"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "web"}},
+	)
+
+	infos := []*resource.Info{
+		newHPAInfo("kube-system", "metrics", "metrics"),
+		newHPAInfo("apps", "web", "web"),
+	}
+	infos, resolvedFrom, err := resolveScaleTargets(context.Background(), client, infos, io.Discard)
+	if err != nil {
+		t.Fatalf("failed to resolve the scale targets: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 scale targets, got %d", len(infos))
+	}
+
+	// the kube-system targets were dropped by the -A filter, the results
+	// only have the apps namespace
+	webResult := &admission.ObjectResult{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "apps", Name: "web"}
+	nsResults := map[string]*admission.NamespaceResult{
+		"apps": {Objects: []*admission.ObjectResult{webResult}},
+	}
+	setResolvedFrom(nsResults, resolvedFrom)
+
+	if expected := []string{"HorizontalPodAutoscaler/web"}; !reflect.DeepEqual(webResult.ResolvedFrom, expected) {
+		t.Errorf("expected the Deployment to be resolved from %v, got %v", expected, webResult.ResolvedFrom)
+	}
+	if _, ok := nsResults["kube-system"]; ok {
+		t.Errorf("the filtered namespace must not be added to the results")
+	}
+}
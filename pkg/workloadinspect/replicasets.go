@@ -0,0 +1,69 @@
+package workloadinspect
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/apiretry"
+)
+
+// withOwnedReplicaSets adds the ReplicaSets controlled by the Deployments
+// among the infos so that the pod templates of the previous rollouts, which
+// may still have pods running, are evaluated as well
+func withOwnedReplicaSets(ctx context.Context, client kubernetes.Interface, infos []*resource.Info, maxRetries int) ([]*resource.Info, error) {
+	seen := map[admission.AdmissionResultsKey]bool{}
+	deployments := map[string]map[types.UID]bool{}
+	for _, info := range infos {
+		seen[admission.AdmissionResultsKey{GVK: info.Object.GetObjectKind().GroupVersionKind(), Namespace: info.Namespace, Name: info.Name}] = true
+		if deployment, ok := info.Object.(*appsv1.Deployment); ok {
+			if deployments[deployment.Namespace] == nil {
+				deployments[deployment.Namespace] = map[types.UID]bool{}
+			}
+			deployments[deployment.Namespace][deployment.UID] = true
+		}
+	}
+
+	replicaSetGVK := appsv1.SchemeGroupVersion.WithKind("ReplicaSet")
+	for ns, uids := range deployments {
+		var replicaSets *appsv1.ReplicaSetList
+		err := apiretry.OnError(maxRetries, func() error {
+			var err error
+			replicaSets, err = client.AppsV1().ReplicaSets(ns).List(ctx, metav1.ListOptions{})
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the replicasets of namespace %q: %w", ns, err)
+		}
+
+		for i := range replicaSets.Items {
+			rs := &replicaSets.Items[i]
+			ref := metav1.GetControllerOf(rs)
+			if ref == nil || !uids[ref.UID] {
+				continue
+			}
+			id := admission.AdmissionResultsKey{GVK: replicaSetGVK, Namespace: rs.Namespace, Name: rs.Name}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			// the typed clients don't set the kind of the objects
+			rs.SetGroupVersionKind(replicaSetGVK)
+			klog.V(4).InfoS("Adding the replicaset of deployment", "replicaSet", klog.KObj(rs), "deployment", ref.Name)
+			infos = append(infos, &resource.Info{
+				Object:    rs,
+				Namespace: rs.Namespace,
+				Name:      rs.Name,
+			})
+		}
+	}
+	return infos, nil
+}
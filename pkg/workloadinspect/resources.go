@@ -0,0 +1,130 @@
+package workloadinspect
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	psadmission "k8s.io/pod-security-admission/admission"
+)
+
+func NewResourcesCommand() *cobra.Command {
+	var noHeaders bool
+
+	cmd := &cobra.Command{
+		Use:          "resources",
+		Short:        "list the kinds of objects that can be evaluated, along with their resource types accepted by inspect-workloads",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			return printSupportedResources(c.OutOrStdout(), noHeaders)
+		},
+	}
+
+	cmd.Flags().BoolVar(&noHeaders, "no-headers", false, "Do not print the header row.")
+	return cmd
+}
+
+// supportedKinds returns the kinds of the scheme whose objects have a pod
+// spec the PodSecurity admission evaluates, mapped to their resources
+func supportedKinds(scheme *runtime.Scheme) map[schema.GroupVersionKind]schema.GroupVersionResource {
+	extractor := psadmission.DefaultPodSpecExtractor{}
+	kinds := map[schema.GroupVersionKind]schema.GroupVersionResource{}
+	for gvk := range scheme.AllKnownTypes() {
+		if gvk.Version == runtime.APIVersionInternal {
+			continue
+		}
+		resource, _ := meta.UnsafeGuessKindToResource(gvk)
+		if extractor.HasPodSpec(resource.GroupResource()) {
+			kinds[gvk] = resource
+		}
+	}
+	return kinds
+}
+
+func printSupportedResources(w io.Writer, noHeaders bool) error {
+	kinds := supportedKinds(scheme)
+	gvks := make([]schema.GroupVersionKind, 0, len(kinds))
+	for gvk := range kinds {
+		gvks = append(gvks, gvk)
+	}
+	sort.Slice(gvks, func(i, j int) bool {
+		return kinds[gvks[i]].GroupResource().String() < kinds[gvks[j]].GroupResource().String()
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	if !noHeaders {
+		fmt.Fprintln(tw, "RESOURCE\tAPIVERSION\tKIND")
+	}
+	for _, gvk := range gvks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", kinds[gvk].GroupResource(), gvk.GroupVersion(), gvk.Kind)
+	}
+	return tw.Flush()
+}
+
+// validateResourceTypes checks that the resource types of the args map to
+// resources of the server that have a pod spec, or to the HPAs if their
+// scale targets are resolved, so that the typos are reported along with the
+// types that can be inspected
+func validateResourceTypes(mapper meta.RESTMapper, args []string, scaleTargets bool) error {
+	extractor := psadmission.DefaultPodSpecExtractor{}
+	supported := strings.Join(strings.Split(supportedResourceTypes(), ","), ", ")
+	for _, resourceType := range argResourceTypes(args) {
+		if resourceType == "all" {
+			continue
+		}
+
+		gvr, err := mapResourceType(mapper, resourceType)
+		if meta.IsNoMatchError(err) {
+			return fmt.Errorf("unknown resource type %q, the supported ones are: %s", resourceType, supported)
+		} else if err != nil {
+			return fmt.Errorf("failed to look up the resource type %q: %w", resourceType, err)
+		}
+		if gvr.GroupResource() == horizontalPodAutoscalers {
+			if !scaleTargets {
+				return fmt.Errorf("resource type %q has no pod template to evaluate, use --resolve-scale-target to evaluate the workloads the autoscalers scale", resourceType)
+			}
+			continue
+		}
+		if !extractor.HasPodSpec(gvr.GroupResource()) {
+			return fmt.Errorf("resource type %q has no pod template to evaluate, the supported ones are: %s", resourceType, supported)
+		}
+	}
+	return nil
+}
+
+// mapResourceType resolves the resource type the same way as the builder,
+// e.g. "deploy", "deployments.apps" or "deployments.v1.apps"
+func mapResourceType(mapper meta.RESTMapper, resourceType string) (schema.GroupVersionResource, error) {
+	fullySpecified, groupResource := schema.ParseResourceArg(resourceType)
+	if fullySpecified != nil {
+		if gvr, err := mapper.ResourceFor(*fullySpecified); err == nil {
+			return gvr, nil
+		}
+	}
+	return mapper.ResourceFor(groupResource.WithVersion(""))
+}
+
+// argResourceTypes returns the resource types of the args of either the
+// "TYPE[,TYPE...] [NAME...]" or the "TYPE/NAME..." form
+func argResourceTypes(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	if !strings.Contains(args[0], "/") {
+		return strings.Split(args[0], ",")
+	}
+
+	types := make([]string, 0, len(args))
+	for _, arg := range args {
+		types = append(types, strings.SplitN(arg, "/", 2)[0])
+	}
+	return types
+}
@@ -0,0 +1,102 @@
+package workloadinspect
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+var horizontalPodAutoscalers = schema.GroupResource{Group: "autoscaling", Resource: "horizontalpodautoscalers"}
+
+// resolveScaleTargets replaces the HorizontalPodAutoscalers among the
+// unstructured infos by the workloads they scale, the other infos are
+// converted to the types of the scheme. The targets of several HPAs, or the
+// ones also listed by themselves, are only evaluated once. The returned map
+// keys the "Kind/name" of the HPAs by the namespace and the "Kind/name" of their targets.
+func resolveScaleTargets(ctx context.Context, client kubernetes.Interface, infos []*resource.Info, errOut io.Writer) ([]*resource.Info, map[string]map[string][]string, error) {
+	resolvedFrom := map[string]map[string][]string{}
+	var workloads, targets []*resource.Info
+	for _, info := range infos {
+		u, ok := info.Object.(*unstructured.Unstructured)
+		if !ok || u.GroupVersionKind().GroupKind() != (schema.GroupKind{Group: horizontalPodAutoscalers.Group, Kind: "HorizontalPodAutoscaler"}) {
+			workloads = append(workloads, info)
+			continue
+		}
+
+		target, err := scaleTarget(ctx, client, u)
+		if apierrors.IsNotFound(err) {
+			fmt.Fprintf(errOut, "Warning: the scale target of HorizontalPodAutoscaler %s/%s does not exist: %v\n", u.GetNamespace(), u.GetName(), err)
+			continue
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve the scale target of HorizontalPodAutoscaler %s/%s: %w", u.GetNamespace(), u.GetName(), err)
+		}
+		if target == nil {
+			// e.g. a custom resource, its pod template is unknown
+			fmt.Fprintf(errOut, "Warning: the scale target of HorizontalPodAutoscaler %s/%s has no pod template to evaluate\n", u.GetNamespace(), u.GetName())
+			continue
+		}
+
+		targetMeta := target.(metav1.Object)
+		targetName := fmt.Sprintf("%s/%s", target.GetObjectKind().GroupVersionKind().Kind, targetMeta.GetName())
+		if resolvedFrom[u.GetNamespace()] == nil {
+			resolvedFrom[u.GetNamespace()] = map[string][]string{}
+		}
+		resolvedFrom[u.GetNamespace()][targetName] = append(resolvedFrom[u.GetNamespace()][targetName], fmt.Sprintf("HorizontalPodAutoscaler/%s", u.GetName()))
+		klog.V(4).InfoS("Resolved the scale target of horizontalpodautoscaler", "hpa", klog.KObj(u), "target", targetName)
+
+		targets = append(targets, &resource.Info{
+			Object:    target,
+			Namespace: targetMeta.GetNamespace(),
+			Name:      targetMeta.GetName(),
+			Source:    info.Source,
+		})
+	}
+
+	workloads, _, invalid := typedInfos(workloads)
+	if len(invalid) > 0 {
+		return nil, nil, utilerrors.NewAggregate(invalid)
+	}
+
+	resolved := make([]*resource.Info, 0, len(workloads)+len(targets))
+	seen := map[admission.AdmissionResultsKey]bool{}
+	for _, info := range append(workloads, targets...) {
+		id := admission.AdmissionResultsKey{GVK: info.Object.GetObjectKind().GroupVersionKind(), Namespace: info.Namespace, Name: info.Name}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		resolved = append(resolved, info)
+	}
+	return resolved, resolvedFrom, nil
+}
+
+// scaleTarget retrieves the workload of the spec.scaleTargetRef of the HPA,
+// the reference is the same in all of the autoscaling versions. It returns
+// nil if the kind of the workload is not supported.
+func scaleTarget(ctx context.Context, client kubernetes.Interface, hpa *unstructured.Unstructured) (runtime.Object, error) {
+	ref, found, err := unstructured.NestedStringMap(hpa.Object, "spec", "scaleTargetRef")
+	if err != nil {
+		return nil, err
+	}
+	if !found || len(ref["kind"]) == 0 || len(ref["name"]) == 0 {
+		return nil, fmt.Errorf("missing spec.scaleTargetRef")
+	}
+
+	return getController(ctx, client, hpa.GetNamespace(), &metav1.OwnerReference{
+		APIVersion: ref["apiVersion"],
+		Kind:       ref["kind"],
+		Name:       ref["name"],
+	})
+}
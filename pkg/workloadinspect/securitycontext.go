@@ -0,0 +1,47 @@
+package workloadinspect
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	psadmission "k8s.io/pod-security-admission/admission"
+	"sigs.k8s.io/yaml"
+)
+
+// parsePodSpecPatch converts the JSON/YAML pod spec fragment to a JSON patch
+// after making sure it is a valid pod spec
+func parsePodSpecPatch(fragment string) ([]byte, error) {
+	patch, err := yaml.YAMLToJSON([]byte(fragment))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &corev1.PodSpec{}); err != nil {
+		return nil, fmt.Errorf("not a pod spec: %w", err)
+	}
+	return patch, nil
+}
+
+// patchPodSpec merges the strategic merge patch onto the pod spec of the
+// object, objects without a pod spec are left as they are
+func patchPodSpec(obj runtime.Object, patch []byte) error {
+	_, podSpec, err := (&psadmission.DefaultPodSpecExtractor{}).ExtractPodSpec(obj)
+	if err != nil || podSpec == nil {
+		return nil
+	}
+
+	original, err := json.Marshal(podSpec)
+	if err != nil {
+		return err
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, patch, corev1.PodSpec{})
+	if err != nil {
+		return err
+	}
+
+	// the extracted pod spec points into the object
+	*podSpec = corev1.PodSpec{}
+	return json.Unmarshal(patched, podSpec)
+}
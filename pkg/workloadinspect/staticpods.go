@@ -0,0 +1,35 @@
+package workloadinspect
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+)
+
+// isMirrorPod returns whether the object is the mirror pod of a static pod,
+// the kubelets create these to represent the pods they run from their local
+// manifests
+func isMirrorPod(obj runtime.Object) bool {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return false
+	}
+	_, ok = pod.Annotations[corev1.MirrorPodAnnotationKey]
+	return ok
+}
+
+// markStaticPods marks the results of the given mirror pods
+func markStaticPods(nsResults map[string]*admission.NamespaceResult, mirrorPods map[admission.AdmissionResultsKey]bool) {
+	for _, nsResult := range nsResults {
+		for _, obj := range nsResult.Objects {
+			key := admission.AdmissionResultsKey{
+				GVK:       schema.FromAPIVersionAndKind(obj.APIVersion, obj.Kind),
+				Namespace: obj.Namespace,
+				Name:      obj.Name,
+			}
+			obj.StaticPod = mirrorPods[key]
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package workloadinspect
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	psapi "k8s.io/pod-security-admission/api"
+
+	"github.com/stlaz/psachecker/pkg/admission"
+	"github.com/stlaz/psachecker/pkg/checker"
+)
+
+// compareVersions sets the levels the namespaces and their objects require
+// under each of the versions. The results were evaluated against the first
+// of them already, the objects are evaluated against the others.
+func compareVersions(
+	ctx context.Context,
+	client kubernetes.Interface,
+	objects []runtime.Object,
+	opts *checker.Options,
+	versions []psapi.Version,
+	nsResults map[string]*admission.NamespaceResult,
+) error {
+	for _, nsResult := range nsResults {
+		nsResult.LevelsByVersion = map[string]admission.Level{versions[0].String(): nsResult.Level}
+		for _, obj := range nsResult.Objects {
+			obj.LevelsByVersion = map[string]admission.Level{versions[0].String(): obj.Result.MostRestrictivePolicy()}
+		}
+	}
+
+	for _, version := range versions[1:] {
+		versionOpts := *opts
+		versionOpts.NamespaceGetter = nil
+		versionOpts.PolicyVersions = admission.PolicyVersions{Enforce: version, Warn: version, Audit: version}
+		results, err := checker.Check(ctx, client, objects, &versionOpts)
+		if err != nil {
+			return err
+		}
+
+		versionNSResults := admission.AggregateResultsPerNamespace(results)
+		for ns, nsResult := range nsResults {
+			// the namespaces without any objects are restricted under any version
+			level := admission.LevelRestrictedValue
+			if versionNSResult, ok := versionNSResults[ns]; ok {
+				level = versionNSResult.Level
+			}
+			nsResult.LevelsByVersion[version.String()] = level
+
+			for _, obj := range nsResult.Objects {
+				key := admission.AdmissionResultsKey{
+					GVK:       schema.FromAPIVersionAndKind(obj.APIVersion, obj.Kind),
+					Namespace: obj.Namespace,
+					Name:      obj.Name,
+				}
+				if result, ok := results[key]; ok {
+					obj.LevelsByVersion[version.String()] = result.MostRestrictivePolicy()
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// setPinnedVersions records the policy versions pinned by the namespaces
+// that the objects were evaluated against, if they differ from the global one
+func setPinnedVersions(nsResults map[string]*admission.NamespaceResult, globalVersion psapi.Version) {
+	for _, nsResult := range nsResults {
+		for _, obj := range nsResult.Objects {
+			if version := obj.Result.PolicyVersion; version != globalVersion {
+				nsResult.PolicyVersion = version.String()
+				break
+			}
+		}
+	}
+}